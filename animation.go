@@ -0,0 +1,255 @@
+package vtf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"time"
+
+	"github.com/galaco/vtf/format"
+	"github.com/galaco/vtf/internal"
+)
+
+const (
+	// defaultFrameDelay is the frame delay used when none is specified
+	defaultFrameDelay = 100 * time.Millisecond
+	// gifAlphaThreshold is the alpha below which a pixel is transparent in a gif
+	gifAlphaThreshold = 128
+	// maxLoopCount is the most plays a gif can store; it counts repeats in 16 bits
+	maxLoopCount = 0xffff + 1
+)
+
+// AnimationOptions configures export of animated textures
+type AnimationOptions struct {
+	// Delay is the time each frame is displayed for. Defaults to 100ms
+	Delay time.Duration
+	// LoopCount is the number of times to play the animation, from 1 to 65536.
+	// 0 or less loops forever, and larger counts are limited to 65536
+	LoopCount int
+}
+
+// delay returns the frame delay, or the default if unset
+func (options *AnimationOptions) delay() time.Duration {
+	if options == nil || options.Delay <= 0 {
+		return defaultFrameDelay
+	}
+
+	return options.Delay
+}
+
+// loopCount returns the number of plays limited to 1-65536, or 0 to loop forever
+func (options *AnimationOptions) loopCount() int {
+	switch {
+	case options == nil || options.LoopCount <= 0:
+		return 0
+	case options.LoopCount > maxLoopCount:
+		return maxLoopCount
+	}
+
+	return options.LoopCount
+}
+
+// WriteGIF writes every frame of the largest mipmap of a vtf as an animated gif.
+// Playback starts at FirstFrame. Each frame has its own median cut palette;
+// pixels with alpha below 128 are transparent.
+func WriteGIF(w io.Writer, vtf *Vtf, options *AnimationOptions) error {
+	frames, err := vtf.animationFrames()
+	if err != nil {
+		return err
+	}
+
+	// image/gif counts repeats after the first play, with -1 playing once
+	loopCount := 0
+	if plays := options.loopCount(); plays == 1 {
+		loopCount = -1
+	} else if plays > 1 {
+		loopCount = plays - 1
+	}
+
+	delay := int(options.delay() / (10 * time.Millisecond))
+	animation := &gif.GIF{
+		LoopCount: loopCount,
+	}
+	for _, frame := range frames {
+		opaquePalette := internal.MedianCutPalette(frame, 255, gifAlphaThreshold)
+		palette := append(color.Palette{}, opaquePalette...)
+		transparentIdx := -1
+		if !frame.Opaque() {
+			transparentIdx = len(palette)
+			palette = append(palette, color.NRGBA{})
+		}
+
+		paletted := image.NewPaletted(frame.Bounds(), palette)
+		cache := map[color.NRGBA]uint8{}
+		for y := 0; y < frame.Rect.Dy(); y++ {
+			for x := 0; x < frame.Rect.Dx(); x++ {
+				c := frame.NRGBAAt(x, y)
+				if c.A < gifAlphaThreshold && transparentIdx >= 0 {
+					paletted.SetColorIndex(x, y, uint8(transparentIdx))
+					continue
+				}
+				c.A = 255
+				idx, ok := cache[c]
+				if !ok {
+					idx = uint8(opaquePalette.Index(c))
+					cache[c] = idx
+				}
+				paletted.SetColorIndex(x, y, idx)
+			}
+		}
+
+		disposal := byte(gif.DisposalNone)
+		if transparentIdx >= 0 {
+			disposal = gif.DisposalBackground
+		}
+		animation.Image = append(animation.Image, paletted)
+		animation.Delay = append(animation.Delay, delay)
+		animation.Disposal = append(animation.Disposal, disposal)
+	}
+
+	return gif.EncodeAll(w, animation)
+}
+
+// WriteAPNG writes every frame of the largest mipmap of a vtf as an animated png.
+// Playback starts at FirstFrame. Frames are stored as 8 bit RGBA, so are lossless
+// for all 8 bit formats.
+func WriteAPNG(w io.Writer, vtf *Vtf, options *AnimationOptions) error {
+	frames, err := vtf.animationFrames()
+	if err != nil {
+		return err
+	}
+
+	bounds := frames[0].Bounds()
+	buf := bytes.NewBuffer([]byte("\x89PNG\r\n\x1a\n"))
+
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(bounds.Dx()))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(bounds.Dy()))
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 6 // colour type: RGBA
+	writePNGChunk(buf, "IHDR", ihdr)
+
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:], uint32(len(frames)))
+	binary.BigEndian.PutUint32(actl[4:], uint32(options.loopCount()))
+	writePNGChunk(buf, "acTL", actl)
+
+	delay := options.delay().Milliseconds()
+	if delay > 0xffff {
+		delay = 0xffff
+	}
+
+	sequence := uint32(0)
+	for idx, frame := range frames {
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], sequence)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(bounds.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(bounds.Dy()))
+		// x & y offset are 0
+		binary.BigEndian.PutUint16(fctl[20:], uint16(delay))
+		binary.BigEndian.PutUint16(fctl[22:], 1000)
+		// dispose & blend ops are 0: none & source
+		writePNGChunk(buf, "fcTL", fctl)
+		sequence++
+
+		data, err := compressPNGImageData(frame)
+		if err != nil {
+			return err
+		}
+		// The first frame is the default image, so is stored as IDAT
+		if idx == 0 {
+			writePNGChunk(buf, "IDAT", data)
+			continue
+		}
+		fdat := make([]byte, 4+len(data))
+		binary.BigEndian.PutUint32(fdat, sequence)
+		copy(fdat[4:], data)
+		writePNGChunk(buf, "fdAT", fdat)
+		sequence++
+	}
+	writePNGChunk(buf, "IEND", nil)
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// animationFrames decodes the largest mipmap of every frame, in playback order
+// starting from FirstFrame. Only the first face & Z slice of that mipmap is read
+func (vtf *Vtf) animationFrames() ([]*image.NRGBA, error) {
+	numFrames := int(vtf.header.Frames)
+	if numFrames == 0 {
+		return nil, fmt.Errorf("%w: frame count 0 has nothing to animate", ErrorInvalidDimensions)
+	}
+	firstFrame := int(vtf.header.FirstFrame)
+	if firstFrame >= numFrames {
+		firstFrame = 0
+	}
+
+	sizes := vtf.mipmapSizes()
+	largest := vtf.LoadedMipmapCount() - 1
+
+	frames := make([]*image.NRGBA, numFrames)
+	for idx := range frames {
+		frame := (firstFrame + idx) % numFrames
+		data, err := vtf.SliceData(largest, frame, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		img, err := DecodeImageData(
+			data,
+			sizes[largest][0],
+			sizes[largest][1],
+			format.Format(vtf.header.HighResImageFormat))
+		if err != nil {
+			return nil, err
+		}
+		frames[idx] = toNRGBA(img)
+	}
+
+	return frames, nil
+}
+
+// compressPNGImageData filters & compresses an image as png image data
+func compressPNGImageData(img *image.NRGBA) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+
+	width := img.Rect.Dx()
+	for y := 0; y < img.Rect.Dy(); y++ {
+		// Filter type 0: none
+		if _, err := zw.Write([]byte{0}); err != nil {
+			return nil, err
+		}
+		offset := img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+y)
+		if _, err := zw.Write(img.Pix[offset : offset+width*4]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writePNGChunk writes a single length prefixed, checksummed png chunk
+func writePNGChunk(buf *bytes.Buffer, chunkType string, data []byte) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(data)))
+	buf.Write(length[:])
+	buf.WriteString(chunkType)
+	buf.Write(data)
+
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(chunkType))
+	crc.Write(data)
+	var checksum [4]byte
+	binary.BigEndian.PutUint32(checksum[:], crc.Sum32())
+	buf.Write(checksum[:])
+}
@@ -0,0 +1,181 @@
+package vtf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/gif"
+	"image/png"
+	"io"
+	"testing"
+	"time"
+)
+
+// newAnimatedTestVtf creates an opaque 3 frame texture where every frame is a solid colour
+func newAnimatedTestVtf(firstFrame uint16) *Vtf {
+	v := newTestVtf(5, 3)
+	v.header.FirstFrame = firstFrame
+	colours := [][4]uint8{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}}
+	for _, mipmap := range v.highResolutionImageData {
+		for frameIdx, frame := range mipmap {
+			data := frame[0][0]
+			for i := 0; i < len(data); i += 4 {
+				copy(data[i:], colours[frameIdx][:])
+			}
+		}
+	}
+	return v
+}
+
+func TestWriteGIF(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteGIF(&buf, newAnimatedTestVtf(1), &AnimationOptions{Delay: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	animation, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(animation.Image) != 3 {
+		t.Fatalf("expected 3 frames, got %d", len(animation.Image))
+	}
+	if animation.Image[0].Bounds() != image.Rect(0, 0, 8, 4) {
+		t.Errorf("unexpected bounds %v", animation.Image[0].Bounds())
+	}
+	if animation.Delay[0] != 5 {
+		t.Errorf("expected delay of 5, got %d", animation.Delay[0])
+	}
+
+	// Playback starts at FirstFrame, which is green
+	r, g, b, _ := animation.Image[0].At(0, 0).RGBA()
+	if r != 0 || g != 0xffff || b != 0 {
+		t.Errorf("expected first frame to be green, got %d %d %d", r, g, b)
+	}
+	r, g, b, _ = animation.Image[2].At(0, 0).RGBA()
+	if r != 0xffff || g != 0 || b != 0 {
+		t.Errorf("expected last frame to be red, got %d %d %d", r, g, b)
+	}
+}
+
+func TestWriteAPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteAPNG(&buf, newAnimatedTestVtf(2), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	// Decoders without APNG support show the default image; the first frame played
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, g, b, _ := img.At(0, 0).RGBA()
+	if r != 0 || g != 0 || b != 0xffff {
+		t.Errorf("expected default image to be blue, got %d %d %d", r, g, b)
+	}
+
+	chunks := map[string]int{}
+	var numFrames uint32
+	for offset := 8; offset < len(data); {
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		chunkType := string(data[offset+4 : offset+8])
+		if chunkType == "acTL" {
+			numFrames = binary.BigEndian.Uint32(data[offset+8:])
+		}
+		if chunkType == "fcTL" {
+			if delay := binary.BigEndian.Uint16(data[offset+8+20:]); delay != 100 {
+				t.Errorf("expected delay of 100, got %d", delay)
+			}
+		}
+		chunks[chunkType]++
+		offset += 12 + length
+	}
+	if numFrames != 3 {
+		t.Errorf("expected 3 frames, got %d", numFrames)
+	}
+	if chunks["fcTL"] != 3 || chunks["fdAT"] != 2 || chunks["IDAT"] != 1 {
+		t.Errorf("unexpected chunks %v", chunks)
+	}
+}
+
+func TestAnimationOptions_LoopCount(t *testing.T) {
+	// Plays, and the loop count gif & apng store for them
+	tests := []struct {
+		loopCount int
+		gif       int
+		apng      uint32
+	}{
+		{0, 0, 0},
+		{-1, 0, 0},
+		{1, -1, 1},
+		{3, 2, 3},
+		{65536, 65535, 65536},
+		{100000, 65535, 65536},
+	}
+
+	for _, tt := range tests {
+		options := &AnimationOptions{LoopCount: tt.loopCount}
+
+		var buf bytes.Buffer
+		if err := WriteGIF(&buf, newAnimatedTestVtf(0), options); err != nil {
+			t.Fatal(err)
+		}
+		animation, err := gif.DecodeAll(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if animation.LoopCount != tt.gif {
+			t.Errorf("loop count %d: expected gif loop count %d, got %d", tt.loopCount, tt.gif, animation.LoopCount)
+		}
+
+		buf.Reset()
+		if err := WriteAPNG(&buf, newAnimatedTestVtf(0), options); err != nil {
+			t.Fatal(err)
+		}
+		data := buf.Bytes()
+		offset := bytes.Index(data, []byte("acTL"))
+		if offset < 0 {
+			t.Fatal("expected an acTL chunk")
+		}
+		if plays := binary.BigEndian.Uint32(data[offset+8:]); plays != tt.apng {
+			t.Errorf("loop count %d: expected %d apng plays, got %d", tt.loopCount, tt.apng, plays)
+		}
+	}
+}
+
+func TestWriteAnimation_NoFrames(t *testing.T) {
+	v := newAnimatedTestVtf(0)
+	v.header.Frames = 0
+
+	var buf bytes.Buffer
+	if err := WriteGIF(&buf, v, nil); !errors.Is(err, ErrorInvalidDimensions) {
+		t.Errorf("gif: expected %v, got %v", ErrorInvalidDimensions, err)
+	}
+	if err := WriteAPNG(&buf, v, nil); !errors.Is(err, ErrorInvalidDimensions) {
+		t.Errorf("apng: expected %v, got %v", ErrorInvalidDimensions, err)
+	}
+}
+
+func TestWriteAPNG_ReadsLargestMipmapOnly(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, newAnimatedTestVtf(0)); err != nil {
+		t.Fatal(err)
+	}
+	source := &countingReaderAt{source: bytes.NewReader(buf.Bytes())}
+	lazy, err := ReadFromReaderAt(source, int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source.read = 0
+	if err := WriteAPNG(io.Discard, lazy, nil); err != nil {
+		t.Fatal(err)
+	}
+	// 3 frames of the 8x4 RGBA8888 mipmap
+	if expected := 3 * 8 * 4 * 4; source.read != expected {
+		t.Errorf("expected %d bytes to be read, read %d", expected, source.read)
+	}
+}
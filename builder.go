@@ -0,0 +1,183 @@
+package vtf
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/galaco/vtf/format"
+	"github.com/galaco/vtf/internal"
+)
+
+var (
+	// ErrorNoImages occurs when building a vtf without any source images
+	ErrorNoImages = errors.New("no source images")
+)
+
+// BuildOptions configures how a vtf is built from images
+type BuildOptions struct {
+	// Version of the vtf to create. Defaults to 7.2
+	Version [2]uint32
+	// Format to store the image data as. Defaults to RGBA8888
+	Format format.Format
+	// Quality of block compression, for compressed formats
+	Quality CompressionQuality
+	// Flags are VTF flags. FlagOneBitAlpha or FlagEightBitAlpha are added
+	// automatically when any image has transparency
+	Flags uint32
+	// FirstFrame is the frame animation starts from
+	FirstFrame uint16
+	// Mipmaps configures mipmap generation. FlagNoMipmaps generates a single level
+	Mipmaps MipmapOptions
+	// NoLowResImage skips generation of the low resolution thumbnail
+	NoLowResImage bool
+}
+
+// NewFromImages builds a vtf from an ordered list of animation frames.
+// All frames must be the same size. Every frame gets a full mip chain, as
+// generated by GenerateMipmaps. The standard low resolution thumbnail is
+// generated from the first frame, unless disabled.
+func NewFromImages(frames []image.Image, options *BuildOptions) (*Vtf, error) {
+	if len(frames) == 0 {
+		return nil, ErrorNoImages
+	}
+	if options == nil {
+		options = &BuildOptions{}
+	}
+
+	bounds := frames[0].Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= 0 || height <= 0 || width > math.MaxUint16 || height > math.MaxUint16 {
+		return nil, fmt.Errorf("%w: width=%d, height=%d", ErrorInvalidDimensions, width, height)
+	}
+	if int(options.FirstFrame) >= len(frames) {
+		return nil, fmt.Errorf("%w: first frame %d of %d frames", ErrorImageDataMismatch, options.FirstFrame, len(frames))
+	}
+
+	header := Header{}
+	header.Version = options.Version
+	if header.Version == [2]uint32{} {
+		header.Version = [2]uint32{7, 2}
+	}
+	header.Width = uint16(width)
+	header.Height = uint16(height)
+	header.Depth = 1
+	header.Flags = options.Flags
+	header.Frames = uint16(len(frames))
+	header.FirstFrame = options.FirstFrame
+	header.BumpmapScale = 1
+	header.HighResImageFormat = uint32(options.Format)
+	header.MipmapCount = 1
+	if header.Flags&FlagNoMipmaps == 0 {
+		header.MipmapCount = uint8(internal.MipmapCount(width, height, 1))
+	}
+	header.LowResImageFormat = noLowResImageFormat
+
+	mipmaps := make([][][][][]uint8, header.MipmapCount)
+	for idx := range mipmaps {
+		mipmaps[idx] = make([][][][]uint8, len(frames))
+	}
+
+	opaque := true
+	for frameIdx, frame := range frames {
+		if frame.Bounds().Dx() != width || frame.Bounds().Dy() != height {
+			return nil, fmt.Errorf("%w: frame %d is %dx%d, expected %dx%d", ErrorInvalidDimensions, frameIdx, frame.Bounds().Dx(), frame.Bounds().Dy(), width, height)
+		}
+		source := toNRGBA(frame)
+		opaque = opaque && source.Opaque()
+
+		for mipIdx, level := range GenerateMipmaps(source, header.Flags, &options.Mipmaps) {
+			data, err := EncodeImageData(level, options.Format, options.Quality)
+			if err != nil {
+				return nil, err
+			}
+			mipmaps[mipIdx][frameIdx] = [][][]uint8{{data}}
+		}
+	}
+
+	if !opaque && options.Format.HasAlpha() {
+		if options.Format == format.Dxt1OneBitAlpha || options.Format == format.BGRA5551 {
+			header.Flags |= FlagOneBitAlpha
+		} else {
+			header.Flags |= FlagEightBitAlpha
+		}
+	}
+
+	vtf := New(header, nil, mipmaps)
+	if !options.NoLowResImage {
+		if err := vtf.setLowResImage(frames[0], &options.Mipmaps); err != nil {
+			return nil, err
+		}
+	}
+
+	return vtf, nil
+}
+
+// NewFromDirectory builds a vtf from a directory of numbered png frames,
+// e.g. frame_0.png, frame_1.png ... Frames are ordered by the last number
+// in their file name.
+func NewFromDirectory(dir string, options *BuildOptions) (*Vtf, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ".png") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		a, b := frameNumber(names[i]), frameNumber(names[j])
+		if a != b {
+			return a < b
+		}
+		return names[i] < names[j]
+	})
+
+	frames := make([]image.Image, len(names))
+	for idx, name := range names {
+		if frames[idx], err = decodePNGFile(filepath.Join(dir, name)); err != nil {
+			return nil, err
+		}
+	}
+
+	return NewFromImages(frames, options)
+}
+
+// frameNumber returns the last number in a file name, or -1 if there is none
+func frameNumber(name string) int {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	end := strings.LastIndexFunc(name, unicode.IsDigit) + 1
+	if end == 0 {
+		return -1
+	}
+	start := strings.LastIndexFunc(name[:end], func(r rune) bool { return !unicode.IsDigit(r) }) + 1
+
+	number, err := strconv.Atoi(name[start:end])
+	if err != nil {
+		return -1
+	}
+
+	return number
+}
+
+// decodePNGFile decodes a single png from the filesystem
+func decodePNGFile(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return png.Decode(file)
+}
@@ -0,0 +1,159 @@
+package vtf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/galaco/vtf/format"
+)
+
+// solidImage creates an image of a single colour
+func solidImage(width int, height int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < len(img.Pix); i += 4 {
+		copy(img.Pix[i:], []uint8{c.R, c.G, c.B, c.A})
+	}
+	return img
+}
+
+func TestNewFromImages(t *testing.T) {
+	colours := []color.NRGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 128}}
+	frames := make([]image.Image, len(colours))
+	for idx, c := range colours {
+		frames[idx] = solidImage(16, 8, c)
+	}
+
+	v, err := NewFromImages(frames, &BuildOptions{FirstFrame: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := v.Header()
+	if header.Frames != 3 || header.FirstFrame != 1 {
+		t.Errorf("unexpected frames %d, first frame %d", header.Frames, header.FirstFrame)
+	}
+	if header.MipmapCount != 5 {
+		t.Errorf("expected 5 mipmaps, got %d", header.MipmapCount)
+	}
+	if header.Flags&FlagEightBitAlpha == 0 {
+		t.Error("expected eight bit alpha flag for translucent frame")
+	}
+
+	// Survives a round trip, with every frame and mipmap in place
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, v); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadFromStream(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for frameIdx, c := range colours {
+		for mipIdx, data := range read.MipmapsForFrame(frameIdx) {
+			if !bytes.Equal(data[:4], []uint8{c.R, c.G, c.B, c.A}) {
+				t.Errorf("frame %d mipmap %d: unexpected colour %v", frameIdx, mipIdx, data[:4])
+			}
+		}
+	}
+}
+
+func TestNewFromImages_Compressed(t *testing.T) {
+	v, err := NewFromImages([]image.Image{gradientImage(16, 16)}, &BuildOptions{Format: format.Dxt5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mipmaps := v.MipmapsForFrame(0)
+	if len(mipmaps) != 5 || len(mipmaps[4]) != 16*16 || len(mipmaps[0]) != 16 {
+		t.Errorf("unexpected mipmap sizes")
+	}
+}
+
+func TestNewFromImages_AlphaFlags(t *testing.T) {
+	transparent := []image.Image{solidImage(8, 8, color.NRGBA{R: 255, A: 128})}
+	tests := map[format.Format]uint32{
+		format.RGBA8888:        FlagEightBitAlpha,
+		format.BGRA5551:        FlagOneBitAlpha,
+		format.Dxt1OneBitAlpha: FlagOneBitAlpha,
+		format.Dxt1:            0,
+		format.RGB888:          0,
+	}
+	for storedFormat, expected := range tests {
+		v, err := NewFromImages(transparent, &BuildOptions{Format: storedFormat})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if flags := v.Header().Flags & (FlagOneBitAlpha | FlagEightBitAlpha); flags != expected {
+			t.Errorf("%s: expected alpha flags %#x, got %#x", storedFormat, expected, flags)
+		}
+	}
+}
+
+func TestNewFromImages_Invalid(t *testing.T) {
+	if _, err := NewFromImages(nil, nil); !errors.Is(err, ErrorNoImages) {
+		t.Errorf("expected %v, got %v", ErrorNoImages, err)
+	}
+
+	frames := []image.Image{gradientImage(8, 8), gradientImage(4, 4)}
+	if _, err := NewFromImages(frames, nil); !errors.Is(err, ErrorInvalidDimensions) {
+		t.Errorf("expected %v, got %v", ErrorInvalidDimensions, err)
+	}
+}
+
+func TestNewFromDirectory(t *testing.T) {
+	dir := t.TempDir()
+	// Numbered out of lexical order
+	for _, idx := range []int{2, 10, 1} {
+		file, err := os.Create(filepath.Join(dir, fmt.Sprintf("frame_%d.png", idx)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := png.Encode(file, solidImage(4, 4, color.NRGBA{R: uint8(idx), A: 255})); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := NewFromDirectory(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Header().Frames != 3 {
+		t.Fatalf("expected 3 frames, got %d", v.Header().Frames)
+	}
+	for frameIdx, expected := range []uint8{1, 2, 10} {
+		if red := v.HighestResolutionImageForFrame(frameIdx)[0]; red != expected {
+			t.Errorf("frame %d: expected red %d, got %d", frameIdx, expected, red)
+		}
+	}
+}
+
+func TestNewFromImages_NoMipmaps(t *testing.T) {
+	v, err := NewFromImages([]image.Image{gradientImage(16, 16)}, &BuildOptions{Flags: FlagNoMipmaps})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Header().MipmapCount != 1 || len(v.MipmapsForFrame(0)) != 1 {
+		t.Errorf("expected a single mipmap, got %d", v.Header().MipmapCount)
+	}
+}
+
+func TestNewFromImages_LowResImage(t *testing.T) {
+	v, err := NewFromImages([]image.Image{gradientImage(512, 128)}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := v.Header()
+	if header.LowResImageWidth != 16 || header.LowResImageHeight != 4 || len(v.LowResImageData()) != 4*8 {
+		t.Errorf("unexpected thumbnail %dx%d", header.LowResImageWidth, header.LowResImageHeight)
+	}
+}
@@ -0,0 +1,23 @@
+package vtf
+
+// CubemapFaceCount is the number of faces of a cubemap, excluding
+// the spheremap face older versions may store
+const CubemapFaceCount = 6
+
+// Cubemap faces, in the order they are stored
+const (
+	// CubemapFaceRight is the +X face
+	CubemapFaceRight = 0
+	// CubemapFaceLeft is the -X face
+	CubemapFaceLeft = 1
+	// CubemapFaceBack is the +Y face
+	CubemapFaceBack = 2
+	// CubemapFaceFront is the -Y face
+	CubemapFaceFront = 3
+	// CubemapFaceUp is the +Z face
+	CubemapFaceUp = 4
+	// CubemapFaceDown is the -Z face
+	CubemapFaceDown = 5
+	// CubemapFaceSphereMap is the spheremap face of pre-7.5 cubemaps
+	CubemapFaceSphereMap = 6
+)
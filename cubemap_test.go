@@ -0,0 +1,71 @@
+package vtf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestHeader_FaceCount(t *testing.T) {
+	tests := []struct {
+		name       string
+		version    uint32
+		flags      uint32
+		firstFrame uint16
+		expected   int
+	}{
+		{"not a cubemap", 5, 0, 0, 1},
+		{"7.5 cubemap", 5, FlagEnvironmentMap, 0, 6},
+		{"7.4 cubemap with spheremap", 4, FlagEnvironmentMap, 0, 7},
+		{"7.4 cubemap without spheremap", 4, FlagEnvironmentMap, 0xffff, 6},
+		{"7.0 cubemap", 0, FlagEnvironmentMap, 0, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := Header{}
+			header.Version = [2]uint32{7, tt.version}
+			header.Flags = tt.flags
+			header.FirstFrame = tt.firstFrame
+			if header.IsCubemap() != (tt.flags&FlagEnvironmentMap != 0) {
+				t.Error("unexpected IsCubemap result")
+			}
+			if actual := header.FaceCount(); actual != tt.expected {
+				t.Errorf("expected %d faces, got %d", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestVtf_Face(t *testing.T) {
+	for _, version := range []uint32{2, 4, 5} {
+		header := newTestVtf(version, 2).Header()
+		header.Flags |= FlagEnvironmentMap
+		expected := newTestVtfFromHeader(header)
+
+		var buf bytes.Buffer
+		if err := WriteToStream(&buf, expected); err != nil {
+			t.Fatalf("7.%d: %s", version, err)
+		}
+		actual, err := ReadFromStream(&buf)
+		if err != nil {
+			t.Fatalf("7.%d: %s", version, err)
+		}
+
+		if !reflect.DeepEqual(actual.HighResImageData(), expected.HighResImageData()) {
+			t.Errorf("7.%d: high resolution data mismatch", version)
+		}
+		faceCount := actual.Header().FaceCount()
+		if faceCount != header.FaceCount() {
+			t.Errorf("7.%d: expected %d faces, got %d", version, header.FaceCount(), faceCount)
+		}
+		for face := 0; face < faceCount; face++ {
+			if !bytes.Equal(actual.Face(3, 1, face), expected.HighResImageData()[3][1][face][0]) {
+				t.Errorf("7.%d: face %d mismatch", version, face)
+			}
+		}
+		if actual.Face(3, 1, faceCount) != nil {
+			t.Errorf("7.%d: expected no data for face %d", version, faceCount)
+		}
+	}
+}
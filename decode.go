@@ -0,0 +1,132 @@
+package vtf
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/galaco/vtf/format"
+	"github.com/galaco/vtf/internal"
+)
+
+var (
+	// ErrorUnsupportedFormat occurs when attempting to decode a format there is no decoder for
+	ErrorUnsupportedFormat = errors.New("unsupported image format")
+	// ErrorImageDataTooSmall occurs when there is less data than the format and dimensions require
+	ErrorImageDataTooSmall = errors.New("image data is smaller than expected")
+	// ErrorNoLowResImage occurs when decoding the thumbnail of a vtf that has none
+	ErrorNoLowResImage = errors.New("vtf has no low resolution image")
+)
+
+// DecodeImageData decodes raw colour data of a single surface into
+// an image. Dxt formats & BC7 are decoded into an *image.NRGBA. ATI1N decodes into an
+// *image.Gray, and ATI2N normal maps into an *image.NRGBA with X in red, Y in
+// green and the reconstructed Z in blue. BC6H decodes into an unclamped *FloatImage.
+// Compressed surfaces smaller than 4x4 must still contain a full block.
+// Uncompressed formats decode to the closest fitting type: I8 to *image.Gray,
+// A8 to *image.Alpha, RGBA16161616 to *image.NRGBA64 and all others to
+// *image.NRGBA. Floating point formats are clamped to [0,1]. P8, depth formats
+// and NVNULL are unsupported.
+func DecodeImageData(data []byte, width int, height int, storedFormat format.Format) (image.Image, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("%w: width=%d, height=%d", ErrorInvalidDimensions, width, height)
+	}
+
+	switch storedFormat {
+	case format.Dxt1, format.Dxt1OneBitAlpha:
+		if err := checkDxtDataSize(data, width, height, 8); err != nil {
+			return nil, err
+		}
+		return internal.DecompressDxt1(data, width, height), nil
+	case format.Dxt3:
+		if err := checkDxtDataSize(data, width, height, 16); err != nil {
+			return nil, err
+		}
+		return internal.DecompressDxt3(data, width, height), nil
+	case format.Dxt5:
+		if err := checkDxtDataSize(data, width, height, 16); err != nil {
+			return nil, err
+		}
+		return internal.DecompressDxt5(data, width, height), nil
+	case format.ATI1N:
+		if err := checkDxtDataSize(data, width, height, 8); err != nil {
+			return nil, err
+		}
+		return internal.DecompressATI1N(data, width, height), nil
+	case format.ATI2N:
+		if err := checkDxtDataSize(data, width, height, 16); err != nil {
+			return nil, err
+		}
+		return internal.DecompressATI2N(data, width, height), nil
+	case format.BC7:
+		if err := checkDxtDataSize(data, width, height, 16); err != nil {
+			return nil, err
+		}
+		return internal.DecompressBC7(data, width, height), nil
+	case format.BC6H:
+		if err := checkDxtDataSize(data, width, height, 16); err != nil {
+			return nil, err
+		}
+		return &FloatImage{
+			Pix:    internal.DecompressBC6H(data, width, height, false),
+			Stride: 4 * width,
+			Rect:   image.Rect(0, 0, width, height),
+		}, nil
+	}
+
+	pixelSize := internal.UncompressedPixelSize(storedFormat)
+	if pixelSize == 0 {
+		return nil, fmt.Errorf("%w: %d", ErrorUnsupportedFormat, storedFormat)
+	}
+	if expected := width * height * pixelSize; len(data) < expected {
+		return nil, fmt.Errorf("%w: got %d bytes, expected %d", ErrorImageDataTooSmall, len(data), expected)
+	}
+
+	return internal.DecodeUncompressed(data, width, height, storedFormat), nil
+}
+
+// DecodeMipmap decodes the first face of a single mipmap & frame into an image.
+// Mipmaps are indexed smallest to largest, as in HighResImageData.
+func (vtf *Vtf) DecodeMipmap(mipmap int, frame int) (image.Image, error) {
+	if mipmap < 0 || mipmap >= int(vtf.header.MipmapCount) {
+		return nil, fmt.Errorf("%w: mipmap %d does not exist", ErrorInvalidMipmapCount, mipmap)
+	}
+	if frame < 0 || frame >= int(vtf.header.Frames) {
+		return nil, fmt.Errorf("%w: frame %d does not exist", ErrorInvalidDimensions, frame)
+	}
+	data, err := vtf.SliceData(mipmap, frame, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := vtf.mipmapSizes()
+
+	return DecodeImageData(
+		data,
+		sizes[mipmap][0],
+		sizes[mipmap][1],
+		format.Format(vtf.header.HighResImageFormat))
+}
+
+// LowResImage decodes the low resolution thumbnail, in whatever format the Header declares
+func (vtf *Vtf) LowResImage() (image.Image, error) {
+	if !vtf.header.HasLowResImage() || len(vtf.lowResolutionImageData) == 0 {
+		return nil, ErrorNoLowResImage
+	}
+
+	return DecodeImageData(
+		vtf.lowResolutionImageData,
+		int(vtf.header.LowResImageWidth),
+		int(vtf.header.LowResImageHeight),
+		format.Format(vtf.header.LowResImageFormat))
+}
+
+// checkDxtDataSize ensures there is enough data for every block of a surface
+func checkDxtDataSize(data []byte, width int, height int, blockSize int) error {
+	blocksWide, blocksHigh := internal.DxtBlockCount(width, height)
+	if expected := blocksWide * blocksHigh * blockSize; len(data) < expected {
+		return fmt.Errorf("%w: got %d bytes, expected %d", ErrorImageDataTooSmall, len(data), expected)
+	}
+
+	return nil
+}
@@ -0,0 +1,372 @@
+package vtf
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/galaco/vtf/format"
+)
+
+func TestDecodeImageData_Dxt1(t *testing.T) {
+	// c0 = pure red, c1 = pure blue, c0 > c1 so 4 colour mode.
+	// Row 0 uses indices 0,1,2,3. Remaining rows use index 0
+	block := []byte{0x00, 0xf8, 0x1f, 0x00, 0xe4, 0x00, 0x00, 0x00}
+
+	img, err := DecodeImageData(block, 4, 4, format.Dxt1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok {
+		t.Fatalf("expected *image.NRGBA, got %T", img)
+	}
+
+	expected := [][4]uint8{
+		{255, 0, 0, 255},
+		{0, 0, 255, 255},
+		{170, 0, 85, 255},
+		{85, 0, 170, 255},
+	}
+	for x, want := range expected {
+		c := nrgba.NRGBAAt(x, 0)
+		if [4]uint8{c.R, c.G, c.B, c.A} != want {
+			t.Errorf("pixel %d: expected %v, got %v", x, want, c)
+		}
+	}
+	if c := nrgba.NRGBAAt(3, 3); c.R != 255 || c.B != 0 {
+		t.Errorf("expected red at 3,3, got %v", c)
+	}
+}
+
+func TestDecodeImageData_Dxt1OneBitAlpha(t *testing.T) {
+	// c0 <= c1 selects 3 colours + transparent black
+	block := []byte{0x1f, 0x00, 0x00, 0xf8, 0xff, 0xff, 0xff, 0xff}
+
+	img, err := DecodeImageData(block, 4, 4, format.Dxt1OneBitAlpha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := img.(*image.NRGBA).NRGBAAt(1, 1); c.A != 0 {
+		t.Errorf("expected transparent pixel, got %v", c)
+	}
+}
+
+func TestDecodeImageData_Dxt3(t *testing.T) {
+	block := []byte{
+		// Alpha: pixel 0 = 0xf, pixel 1 = 0x0, everything else 0x8
+		0x0f, 0x88, 0x88, 0x88, 0x88, 0x88, 0x88, 0x88,
+		// Colour: all white
+		0xff, 0xff, 0xff, 0xff, 0x00, 0x00, 0x00, 0x00,
+	}
+
+	img, err := DecodeImageData(block, 4, 4, format.Dxt3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nrgba := img.(*image.NRGBA)
+	if a := nrgba.NRGBAAt(0, 0).A; a != 255 {
+		t.Errorf("expected alpha 255, got %d", a)
+	}
+	if a := nrgba.NRGBAAt(1, 0).A; a != 0 {
+		t.Errorf("expected alpha 0, got %d", a)
+	}
+	if a := nrgba.NRGBAAt(2, 0).A; a != 0x88 {
+		t.Errorf("expected alpha 0x88, got %d", a)
+	}
+}
+
+func TestDecodeImageData_Dxt5(t *testing.T) {
+	block := []byte{
+		// a0 = 255, a1 = 0; pixel 0 uses index 0, pixel 1 index 1, pixel 2 index 7
+		0xff, 0x00, 0xc8, 0x01, 0x00, 0x00, 0x00, 0x00,
+		// Colour: all black
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+
+	img, err := DecodeImageData(block, 4, 4, format.Dxt5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nrgba := img.(*image.NRGBA)
+	for x, want := range []uint8{255, 0, 36} {
+		if a := nrgba.NRGBAAt(x, 0).A; a != want {
+			t.Errorf("pixel %d: expected alpha %d, got %d", x, want, a)
+		}
+	}
+}
+
+func TestDecodeImageData_ATI1N(t *testing.T) {
+	// v0 = 255, v1 = 0; pixel 0 uses index 0, pixel 1 index 1, pixel 2 index 7
+	block := []byte{0xff, 0x00, 0xc8, 0x01, 0x00, 0x00, 0x00, 0x00}
+
+	img, err := DecodeImageData(block, 4, 4, format.ATI1N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gray, ok := img.(*image.Gray)
+	if !ok {
+		t.Fatalf("expected *image.Gray, got %T", img)
+	}
+	for x, want := range []uint8{255, 0, 36} {
+		if y := gray.GrayAt(x, 0).Y; y != want {
+			t.Errorf("pixel %d: expected %d, got %d", x, want, y)
+		}
+	}
+}
+
+func TestDecodeImageData_ATI2N(t *testing.T) {
+	block := []byte{
+		// X: every pixel 128 (0)
+		0x80, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		// Y: pixel 0 uses index 0 (255, 1), all others index 1 (128, 0)
+		0xff, 0x80, 0x48, 0x92, 0x24, 0x49, 0x92, 0x24,
+	}
+
+	img, err := DecodeImageData(block, 4, 4, format.ATI2N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nrgba := img.(*image.NRGBA)
+	// A normal along Y has no Z; one with X & Y of 0 points along Z
+	if c := nrgba.NRGBAAt(0, 0); c != (color.NRGBA{128, 255, 128, 255}) {
+		t.Errorf("pixel 0: unexpected normal %v", c)
+	}
+	if c := nrgba.NRGBAAt(1, 0); c != (color.NRGBA{128, 128, 255, 255}) {
+		t.Errorf("pixel 1: unexpected normal %v", c)
+	}
+}
+
+func TestDecodeImageData_BC7(t *testing.T) {
+	tests := map[string]struct {
+		block    []byte
+		expected map[int]color.NRGBA
+	}{
+		// Endpoints 0 & 255; pixel 0 uses index 0, pixel 1 index 15 and pixel 2 index 8
+		"mode 6": {
+			[]byte{0x40, 0xc0, 0x1f, 0xf0, 0x07, 0xfc, 0x01, 0x7f, 0xf1, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			map[int]color.NRGBA{0: {0, 0, 0, 0}, 1: {255, 255, 255, 255}, 2: {135, 135, 135, 135}, 15: {0, 0, 0, 0}},
+		},
+		// Rotation 1 swaps red & alpha; pixel 1 has white colour & no alpha, pixel 2 the opposite
+		"mode 5 rotation": {
+			[]byte{0x60, 0x80, 0x3f, 0xe0, 0x0f, 0xf8, 0x03, 0xfc, 0x1b, 0x00, 0x00, 0x00, 0x30, 0x00, 0x00, 0x00},
+			map[int]color.NRGBA{0: {0, 0, 0, 0}, 1: {0, 255, 255, 255}, 2: {255, 0, 0, 0}},
+		},
+		// Partition 13 splits the top & bottom halves into red & blue subsets, with shared p-bits of 1
+		"mode 1 partition": {
+			[]byte{0x36, 0xff, 0x0f, 0x00, 0x00, 0x00, 0x00, 0x00, 0xf0, 0xff, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00},
+			map[int]color.NRGBA{0: {255, 2, 2, 255}, 7: {255, 2, 2, 255}, 8: {2, 2, 255, 255}, 15: {2, 2, 255, 255}},
+		},
+		"reserved mode": {
+			make([]byte, 16),
+			map[int]color.NRGBA{0: {0, 0, 0, 0}},
+		},
+	}
+
+	for name, tt := range tests {
+		img, err := DecodeImageData(tt.block, 4, 4, format.BC7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nrgba := img.(*image.NRGBA)
+		for pixel, expected := range tt.expected {
+			if c := nrgba.NRGBAAt(pixel%4, pixel/4); c != expected {
+				t.Errorf("%s: pixel %d: expected %v, got %v", name, pixel, expected, c)
+			}
+		}
+	}
+}
+
+func TestDecodeImageData_BC6H(t *testing.T) {
+	tests := map[string]struct {
+		block    []byte
+		expected map[int][3]float32
+	}{
+		// Endpoints 0 & 1023; pixel 0 uses index 0, pixel 1 index 15 and pixel 2 index 8
+		"untransformed": {
+			[]byte{0x03, 0x00, 0x00, 0x00, 0xf8, 0xff, 0xff, 0xff, 0xf1, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			map[int][3]float32{
+				0: {0, 0, 0},
+				1: {65504, 65504, 65504},
+				2: {2.935546875, 2.935546875, 2.935546875},
+			},
+		},
+		// Endpoints 1000, 500 & 0 with deltas of -8, 8 & 3; pixel 1 uses the second endpoint
+		"transformed": {
+			[]byte{0x07, 0x7d, 0xfa, 0x00, 0xc0, 0x0f, 0x81, 0x01, 0xf0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			map[int][3]float32{
+				0: {1.1435546875, 0.006153106689453125, 0},
+				1: {1.0224609375, 0.006626129150390625, 3.2186508178710938e-06},
+			},
+		},
+		// Partition 13 splits the top & bottom halves. The bottom region has deltas of 15 & -16
+		// from endpoints of 100, 200 & 300; its anchor, pixel 15, uses index 0 and the others 7
+		"two regions": {
+			[]byte{0x90, 0x0c, 0x64, 0x58, 0x02, 0x1f, 0x00, 0xe0, 0x1f, 0xa8, 0x01, 0x00, 0x00, 0xfe, 0xff, 0x3f},
+			map[int][3]float32{
+				0:  {0.0002543926239013672, 0.0020885467529296875, 0.0171356201171875},
+				7:  {0.0002543926239013672, 0.0020885467529296875, 0.0171356201171875},
+				8:  {0.0001901388168334961, 0.0015478134155273438, 0.01259613037109375},
+				15: {0.00036525726318359375, 0.0029754638671875, 0.02423095703125},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		img, err := DecodeImageData(tt.block, 4, 4, format.BC6H)
+		if err != nil {
+			t.Fatal(err)
+		}
+		float, ok := img.(*FloatImage)
+		if !ok {
+			t.Fatalf("expected *FloatImage, got %T", img)
+		}
+		for pixel, expected := range tt.expected {
+			c := float.FloatAt(pixel%4, pixel/4)
+			if c != [4]float32{expected[0], expected[1], expected[2], 1} {
+				t.Errorf("%s: pixel %d: expected %v, got %v", name, pixel, expected, c)
+			}
+		}
+	}
+
+	// Values beyond 1 are clamped when used as an image.Image
+	img, _ := DecodeImageData(tests["untransformed"].block, 4, 4, format.BC6H)
+	if c := img.At(1, 0); c != (color.NRGBA64{0xffff, 0xffff, 0xffff, 0xffff}) {
+		t.Errorf("expected clamped white, got %v", c)
+	}
+}
+
+func TestDecodeImageData_DxtPadding(t *testing.T) {
+	block := []byte{0x00, 0xf8, 0x1f, 0x00, 0x00, 0x00, 0x00, 0x00}
+
+	img, err := DecodeImageData(block, 2, 1, format.Dxt1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 2, 1) {
+		t.Errorf("unexpected bounds %v", img.Bounds())
+	}
+}
+
+func TestDecodeImageData_TooSmall(t *testing.T) {
+	_, err := DecodeImageData(make([]byte, 8), 8, 4, format.Dxt1)
+	if !errors.Is(err, ErrorImageDataTooSmall) {
+		t.Errorf("expected %v, got %v", ErrorImageDataTooSmall, err)
+	}
+}
+
+func TestDecodeImageData_LowResThumbnail(t *testing.T) {
+	vtf, err := ReadFromFile("samples/read/test.vtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := vtf.Header()
+	img, err := DecodeImageData(vtf.LowResImageData(), int(header.LowResImageWidth), int(header.LowResImageHeight), format.Format(header.LowResImageFormat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != int(header.LowResImageWidth) {
+		t.Errorf("expected width %d, got %d", header.LowResImageWidth, img.Bounds().Dx())
+	}
+}
+
+func TestDecodeImageData_Uncompressed(t *testing.T) {
+	tests := []struct {
+		format   format.Format
+		data     []byte
+		expected color.NRGBA
+	}{
+		{format.RGBA8888, []byte{1, 2, 3, 4}, color.NRGBA{1, 2, 3, 4}},
+		{format.ABGR8888, []byte{4, 3, 2, 1}, color.NRGBA{1, 2, 3, 4}},
+		{format.RGB888, []byte{1, 2, 3}, color.NRGBA{1, 2, 3, 255}},
+		{format.BGR888, []byte{3, 2, 1}, color.NRGBA{1, 2, 3, 255}},
+		{format.RGB565, []byte{0x1f, 0x00}, color.NRGBA{255, 0, 0, 255}},
+		{format.BGR565, []byte{0x00, 0xf8}, color.NRGBA{255, 0, 0, 255}},
+		{format.IA88, []byte{7, 8}, color.NRGBA{7, 7, 7, 8}},
+		{format.RGB888BLUESCREEN, []byte{0, 0, 255}, color.NRGBA{0, 0, 0, 0}},
+		{format.BGR888BLUESCREEN, []byte{3, 2, 1}, color.NRGBA{1, 2, 3, 255}},
+		{format.ARGB8888, []byte{4, 1, 2, 3}, color.NRGBA{1, 2, 3, 4}},
+		{format.BGRA8888, []byte{3, 2, 1, 4}, color.NRGBA{1, 2, 3, 4}},
+		{format.BGRX8888, []byte{3, 2, 1, 0}, color.NRGBA{1, 2, 3, 255}},
+		{format.BGRX5551, []byte{0x1f, 0x00}, color.NRGBA{0, 0, 255, 255}},
+		{format.BGRA4444, []byte{0x0f, 0xf0}, color.NRGBA{0, 0, 255, 255}},
+		{format.BGRA5551, []byte{0x00, 0x7c}, color.NRGBA{255, 0, 0, 0}},
+		{format.UV88, []byte{1, 2}, color.NRGBA{1, 2, 0, 255}},
+		{format.UVWQ8888, []byte{1, 2, 3, 4}, color.NRGBA{1, 2, 3, 4}},
+		{format.UVLX8888, []byte{1, 2, 3, 4}, color.NRGBA{1, 2, 3, 4}},
+		// 1.0, 0.5, 0, 2.0 as half floats
+		{format.RGBA16161616F, []byte{0x00, 0x3c, 0x00, 0x38, 0x00, 0x00, 0x00, 0x40}, color.NRGBA{255, 128, 0, 255}},
+		// 0.5, 1.0, 0, 2.0 as floats
+		{format.R32F, []byte{0, 0, 0, 0x3f}, color.NRGBA{128, 128, 128, 255}},
+		{format.RGB323232F, []byte{0, 0, 0x80, 0x3f, 0, 0, 0, 0, 0, 0, 0, 0x40}, color.NRGBA{255, 0, 255, 255}},
+		{format.RGBA32323232F, []byte{0, 0, 0, 0x3f, 0, 0, 0x80, 0x3f, 0, 0, 0, 0, 0, 0, 0, 0x40}, color.NRGBA{128, 255, 0, 255}},
+	}
+
+	for _, tt := range tests {
+		img, err := DecodeImageData(tt.data, 1, 1, tt.format)
+		if err != nil {
+			t.Errorf("format %d: %s", tt.format, err)
+			continue
+		}
+		nrgba, ok := img.(*image.NRGBA)
+		if !ok {
+			t.Errorf("format %d: expected *image.NRGBA, got %T", tt.format, img)
+			continue
+		}
+		if c := nrgba.NRGBAAt(0, 0); c != tt.expected {
+			t.Errorf("format %d: expected %v, got %v", tt.format, tt.expected, c)
+		}
+	}
+}
+
+func TestDecodeImageData_UncompressedImageTypes(t *testing.T) {
+	img, err := DecodeImageData([]byte{1, 2, 3, 4}, 2, 2, format.I8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gray, ok := img.(*image.Gray); !ok || gray.GrayAt(1, 1).Y != 4 {
+		t.Errorf("unexpected I8 decode result %T", img)
+	}
+
+	img, err = DecodeImageData([]byte{1, 2, 3, 4}, 2, 2, format.A8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alpha, ok := img.(*image.Alpha); !ok || alpha.AlphaAt(0, 1).A != 3 {
+		t.Errorf("unexpected A8 decode result %T", img)
+	}
+
+	img, err = DecodeImageData([]byte{0x34, 0x12, 0, 0, 0, 0, 0xff, 0xff}, 1, 1, format.RGBA16161616)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nrgba64, ok := img.(*image.NRGBA64); !ok || nrgba64.NRGBA64At(0, 0) != (color.NRGBA64{0x1234, 0, 0, 0xffff}) {
+		t.Errorf("unexpected RGBA16161616 decode result %T", img)
+	}
+
+	if _, err = DecodeImageData([]byte{0}, 1, 1, format.P8); !errors.Is(err, ErrorUnsupportedFormat) {
+		t.Errorf("expected %v, got %v", ErrorUnsupportedFormat, err)
+	}
+}
+
+func TestVtf_DecodeMipmap(t *testing.T) {
+	vtf, err := ReadFromFile("samples/read/test.vtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := vtf.Header()
+	img, err := vtf.DecodeMipmap(int(header.MipmapCount)-1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds() != image.Rect(0, 0, int(header.Width), int(header.Height)) {
+		t.Errorf("unexpected bounds %v", img.Bounds())
+	}
+
+	if _, err = vtf.DecodeMipmap(int(header.MipmapCount), 0); !errors.Is(err, ErrorInvalidMipmapCount) {
+		t.Errorf("expected %v, got %v", ErrorInvalidMipmapCount, err)
+	}
+}
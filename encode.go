@@ -0,0 +1,70 @@
+package vtf
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+
+	"github.com/galaco/vtf/format"
+	"github.com/galaco/vtf/internal"
+)
+
+// CompressionQuality selects the speed/quality tradeoff of block compression
+type CompressionQuality int
+
+const (
+	// CompressionQualityFast uses range fitting; the colours at either extreme
+	// of each block are used as its endpoints
+	CompressionQualityFast = CompressionQuality(0)
+	// CompressionQualityHigh uses cluster fitting; every ordered clustering of each block
+	// is searched for the endpoints with least error
+	CompressionQualityHigh = CompressionQuality(1)
+)
+
+// EncodeImageData encodes an image into the raw colour data of a single surface.
+// Supported formats are Dxt1, Dxt1OneBitAlpha, Dxt3, Dxt5, BC7, and all 8 bit per channel
+// uncompressed formats. Quality only affects compressed formats. Output is deterministic;
+// the same image, format and quality always produce the same data.
+func EncodeImageData(img image.Image, storedFormat format.Format, quality CompressionQuality) ([]byte, error) {
+	bounds := img.Bounds()
+	if bounds.Dx() <= 0 || bounds.Dy() <= 0 {
+		return nil, fmt.Errorf("%w: width=%d, height=%d", ErrorInvalidDimensions, bounds.Dx(), bounds.Dy())
+	}
+
+	dxtQuality := internal.DxtQualityRangeFit
+	if quality == CompressionQualityHigh {
+		dxtQuality = internal.DxtQualityClusterFit
+	}
+
+	switch storedFormat {
+	case format.Dxt1:
+		return internal.CompressDxt1(toNRGBA(img), false, dxtQuality), nil
+	case format.Dxt1OneBitAlpha:
+		return internal.CompressDxt1(toNRGBA(img), true, dxtQuality), nil
+	case format.Dxt3:
+		return internal.CompressDxt3(toNRGBA(img), dxtQuality), nil
+	case format.Dxt5:
+		return internal.CompressDxt5(toNRGBA(img), dxtQuality), nil
+	case format.BC7:
+		return internal.CompressBC7(toNRGBA(img), dxtQuality), nil
+	}
+
+	if data := internal.EncodeUncompressed(toNRGBA(img), storedFormat); data != nil {
+		return data, nil
+	}
+
+	return nil, fmt.Errorf("%w: %d", ErrorUnsupportedFormat, storedFormat)
+}
+
+// toNRGBA converts any image into a non-premultiplied RGBA image
+func toNRGBA(img image.Image) *image.NRGBA {
+	if nrgba, ok := img.(*image.NRGBA); ok {
+		return nrgba
+	}
+
+	bounds := img.Bounds()
+	nrgba := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+
+	return nrgba
+}
@@ -0,0 +1,268 @@
+package vtf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/galaco/vtf/format"
+)
+
+// gradientImage creates a deterministic image with smooth colour & alpha changes
+func gradientImage(width int, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(x * 255 / width),
+				G: uint8(y * 255 / height),
+				B: uint8((x + y) * 127 / (width + height)),
+				A: uint8(255 - x*255/width),
+			})
+		}
+	}
+	return img
+}
+
+// squaredError sums the squared per channel difference between 2 images
+func squaredError(a image.Image, b image.Image, withAlpha bool) int {
+	total := 0
+	bounds := a.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			ca := color.NRGBAModel.Convert(a.At(x, y)).(color.NRGBA)
+			cb := color.NRGBAModel.Convert(b.At(x, y)).(color.NRGBA)
+			diffs := []int{int(ca.R) - int(cb.R), int(ca.G) - int(cb.G), int(ca.B) - int(cb.B)}
+			if withAlpha {
+				diffs = append(diffs, int(ca.A)-int(cb.A))
+			}
+			for _, d := range diffs {
+				total += d * d
+			}
+		}
+	}
+	return total
+}
+
+func TestEncodeImageData_SolidColour(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for i := 0; i < len(img.Pix); i += 4 {
+		copy(img.Pix[i:], []uint8{255, 0, 255, 255})
+	}
+
+	for _, f := range []format.Format{format.Dxt1, format.Dxt1OneBitAlpha, format.Dxt3, format.Dxt5} {
+		for _, quality := range []CompressionQuality{CompressionQualityFast, CompressionQualityHigh} {
+			data, err := EncodeImageData(img, f, quality)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := DecodeImageData(data, 8, 8, f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if e := squaredError(img, decoded, true); e != 0 {
+				t.Errorf("format %d quality %d: expected exact match, got error %d", f, quality, e)
+			}
+		}
+	}
+
+	for _, c := range []color.NRGBA{{255, 0, 255, 255}, {100, 150, 200, 255}, {10, 20, 30, 255}} {
+		checkBC7Opaque(t, fmt.Sprint(c), solidImage(8, 8, c))
+	}
+}
+
+// checkerboardImage creates an image alternating between 2 colours
+func checkerboardImage(width int, height int, a color.NRGBA, b color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := a
+			if (x+y)%2 == 1 {
+				c = b
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestEncodeImageData_OpposingChannels(t *testing.T) {
+	// Red falls as green rises, so the principal axis is orthogonal to grey
+	img := checkerboardImage(8, 8, color.NRGBA{R: 255, A: 255}, color.NRGBA{G: 255, A: 255})
+
+	for _, f := range []format.Format{format.Dxt1, format.Dxt3, format.Dxt5} {
+		for _, quality := range []CompressionQuality{CompressionQualityFast, CompressionQualityHigh} {
+			data, err := EncodeImageData(img, f, quality)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := DecodeImageData(data, 8, 8, f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if e := squaredError(img, decoded, true); e != 0 {
+				t.Errorf("format %d quality %d: expected exact match, got error %d", f, quality, e)
+			}
+		}
+	}
+
+	checkBC7Opaque(t, "checkerboard", img)
+}
+
+// maxChannelError returns the largest per channel difference of the colour & alpha of 2 images
+func maxChannelError(a image.Image, b image.Image) (int, int) {
+	abs := func(v int) int {
+		if v < 0 {
+			return -v
+		}
+		return v
+	}
+	colour, alpha := 0, 0
+	bounds := a.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			ca := color.NRGBAModel.Convert(a.At(x, y)).(color.NRGBA)
+			cb := color.NRGBAModel.Convert(b.At(x, y)).(color.NRGBA)
+			for _, d := range []int{int(ca.R) - int(cb.R), int(ca.G) - int(cb.G), int(ca.B) - int(cb.B)} {
+				if abs(d) > colour {
+					colour = abs(d)
+				}
+			}
+			if d := int(ca.A) - int(cb.A); abs(d) > alpha {
+				alpha = abs(d)
+			}
+		}
+	}
+	return colour, alpha
+}
+
+// checkBC7Opaque encodes an opaque image as BC7, which must keep alpha exact.
+// Opaque mode 6 endpoints only store odd channel values, so colours may be 1 out
+func checkBC7Opaque(t *testing.T, name string, img *image.NRGBA) {
+	t.Helper()
+	for _, quality := range []CompressionQuality{CompressionQualityFast, CompressionQualityHigh} {
+		data, err := EncodeImageData(img, format.BC7, quality)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := DecodeImageData(data, img.Rect.Dx(), img.Rect.Dy(), format.BC7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if colour, alpha := maxChannelError(img, decoded); colour > 1 || alpha != 0 {
+			t.Errorf("%s quality %d: expected exact alpha & colour within 1, got errors of %d & %d", name, quality, colour, alpha)
+		}
+	}
+}
+
+func TestEncodeImageData_Quality(t *testing.T) {
+	img := gradientImage(32, 32)
+
+	for _, f := range []format.Format{format.Dxt1, format.Dxt3, format.Dxt5, format.BC7} {
+		totals := map[CompressionQuality]int{}
+		for _, quality := range []CompressionQuality{CompressionQualityFast, CompressionQualityHigh} {
+			data, err := EncodeImageData(img, f, quality)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := DecodeImageData(data, 32, 32, f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			totals[quality] = squaredError(img, decoded, false)
+			if perPixel := totals[quality] / (32 * 32); perPixel > 128 {
+				t.Errorf("format %d quality %d: error per pixel %d is too high", f, quality, perPixel)
+			}
+		}
+		if totals[CompressionQualityHigh] > totals[CompressionQualityFast] {
+			t.Errorf("format %d: high quality error %d exceeds fast error %d", f, totals[CompressionQualityHigh], totals[CompressionQualityFast])
+		}
+	}
+}
+
+func TestEncodeImageData_Deterministic(t *testing.T) {
+	img := gradientImage(16, 12)
+
+	for _, quality := range []CompressionQuality{CompressionQualityFast, CompressionQualityHigh} {
+		first, err := EncodeImageData(img, format.Dxt5, quality)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := EncodeImageData(img, format.Dxt5, quality)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(first, second) {
+			t.Errorf("quality %d: output differs between runs", quality)
+		}
+	}
+}
+
+func TestEncodeImageData_OneBitAlpha(t *testing.T) {
+	img := gradientImage(8, 8)
+
+	data, err := EncodeImageData(img, format.Dxt1OneBitAlpha, CompressionQualityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeImageData(data, 8, 8, format.Dxt1OneBitAlpha)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for x := 0; x < 8; x++ {
+		expectTransparent := img.NRGBAAt(x, 0).A < 128
+		isTransparent := decoded.(*image.NRGBA).NRGBAAt(x, 0).A == 0
+		if expectTransparent != isTransparent {
+			t.Errorf("pixel %d: expected transparent=%t", x, expectTransparent)
+		}
+	}
+}
+
+func TestEncodeImageData_Padding(t *testing.T) {
+	img := gradientImage(6, 3)
+
+	data, err := EncodeImageData(img, format.Dxt1, CompressionQualityFast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 2*8 {
+		t.Errorf("expected 2 blocks, got %d bytes", len(data))
+	}
+}
+
+func TestEncodeImageData_UnsupportedFormat(t *testing.T) {
+	_, err := EncodeImageData(gradientImage(4, 4), format.P8, CompressionQualityFast)
+	if !errors.Is(err, ErrorUnsupportedFormat) {
+		t.Errorf("expected %v, got %v", ErrorUnsupportedFormat, err)
+	}
+}
+
+func TestEncodeImageData_Uncompressed(t *testing.T) {
+	img := gradientImage(8, 8)
+
+	for _, f := range []format.Format{format.RGBA8888, format.ABGR8888, format.ARGB8888, format.BGRA8888, format.UVWQ8888} {
+		data, err := EncodeImageData(img, f, CompressionQualityFast)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := DecodeImageData(data, 8, 8, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e := squaredError(img, decoded, true); e != 0 {
+			t.Errorf("format %d: expected lossless round trip, got error %d", f, e)
+		}
+	}
+
+	data, err := EncodeImageData(img, format.BGR565, CompressionQualityFast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 8*8*2 {
+		t.Errorf("expected %d bytes, got %d", 8*8*2, len(data))
+	}
+}
@@ -0,0 +1,83 @@
+package vtf
+
+import (
+	"image"
+	"image/color"
+)
+
+// FloatImage is an in memory image of linear, non-premultiplied float32 RGBA pixels,
+// as decoded from HDR formats. Pixels are unclamped; At clamps them to [0,1] for
+// use as a standard image.Image, FloatAt returns them as stored.
+type FloatImage struct {
+	// Pix holds the R, G, B & A of each pixel, row by row
+	Pix []float32
+	// Stride is the number of Pix values between vertically adjacent pixels
+	Stride int
+	// Rect is the image's bounds
+	Rect image.Rectangle
+}
+
+// NewFloatImage returns a new FloatImage with the given bounds
+func NewFloatImage(r image.Rectangle) *FloatImage {
+	return &FloatImage{
+		Pix:    make([]float32, 4*r.Dx()*r.Dy()),
+		Stride: 4 * r.Dx(),
+		Rect:   r,
+	}
+}
+
+// ColorModel returns the colour model of At
+func (img *FloatImage) ColorModel() color.Model {
+	return color.NRGBA64Model
+}
+
+// Bounds returns the domain for which At can return non-zero colours
+func (img *FloatImage) Bounds() image.Rectangle {
+	return img.Rect
+}
+
+// At returns the colour of a pixel, clamped to [0,1]
+func (img *FloatImage) At(x int, y int) color.Color {
+	c := img.FloatAt(x, y)
+	return color.NRGBA64{
+		R: unitFloatToUint16(c[0]),
+		G: unitFloatToUint16(c[1]),
+		B: unitFloatToUint16(c[2]),
+		A: unitFloatToUint16(c[3]),
+	}
+}
+
+// FloatAt returns the unclamped R, G, B & A of a pixel
+func (img *FloatImage) FloatAt(x int, y int) [4]float32 {
+	if !(image.Point{X: x, Y: y}.In(img.Rect)) {
+		return [4]float32{}
+	}
+	i := img.PixOffset(x, y)
+
+	return [4]float32{img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3]}
+}
+
+// SetFloat sets the R, G, B & A of a pixel
+func (img *FloatImage) SetFloat(x int, y int, c [4]float32) {
+	if !(image.Point{X: x, Y: y}.In(img.Rect)) {
+		return
+	}
+	copy(img.Pix[img.PixOffset(x, y):], c[:])
+}
+
+// PixOffset returns the index of the first element of Pix that corresponds to a pixel
+func (img *FloatImage) PixOffset(x int, y int) int {
+	return (y-img.Rect.Min.Y)*img.Stride + (x-img.Rect.Min.X)*4
+}
+
+// unitFloatToUint16 maps [0,1] to [0,65535], clamping values outside it
+func unitFloatToUint16(f float32) uint16 {
+	switch {
+	case f != f || f <= 0:
+		return 0
+	case f >= 1:
+		return 0xffff
+	}
+
+	return uint16(f*0xffff + 0.5)
+}
@@ -0,0 +1,155 @@
+package format
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrorUnknownFormat occurs when parsing a name that is not a format
+	ErrorUnknownFormat = errors.New("unknown format")
+)
+
+// info describes how a format is stored
+type info struct {
+	name string
+	// bytesPerBlock is the size of a block; a single pixel for uncompressed formats
+	bytesPerBlock int
+	// blockSize is the width & height of a block in pixels
+	blockSize int
+	channels  int
+	alpha     bool
+	float     bool
+}
+
+// formats describes every known format
+var formats = map[Format]info{
+	RGBA8888:         {name: "RGBA8888", bytesPerBlock: 4, blockSize: 1, channels: 4, alpha: true},
+	ABGR8888:         {name: "ABGR8888", bytesPerBlock: 4, blockSize: 1, channels: 4, alpha: true},
+	RGB888:           {name: "RGB888", bytesPerBlock: 3, blockSize: 1, channels: 3},
+	BGR888:           {name: "BGR888", bytesPerBlock: 3, blockSize: 1, channels: 3},
+	RGB565:           {name: "RGB565", bytesPerBlock: 2, blockSize: 1, channels: 3},
+	I8:               {name: "I8", bytesPerBlock: 1, blockSize: 1, channels: 1},
+	IA88:             {name: "IA88", bytesPerBlock: 2, blockSize: 1, channels: 2, alpha: true},
+	P8:               {name: "P8", bytesPerBlock: 1, blockSize: 1, channels: 1},
+	A8:               {name: "A8", bytesPerBlock: 1, blockSize: 1, channels: 1, alpha: true},
+	RGB888BLUESCREEN: {name: "RGB888BLUESCREEN", bytesPerBlock: 3, blockSize: 1, channels: 3, alpha: true},
+	BGR888BLUESCREEN: {name: "BGR888BLUESCREEN", bytesPerBlock: 3, blockSize: 1, channels: 3, alpha: true},
+	ARGB8888:         {name: "ARGB8888", bytesPerBlock: 4, blockSize: 1, channels: 4, alpha: true},
+	BGRA8888:         {name: "BGRA8888", bytesPerBlock: 4, blockSize: 1, channels: 4, alpha: true},
+	Dxt1:             {name: "Dxt1", bytesPerBlock: 8, blockSize: 4, channels: 3},
+	Dxt3:             {name: "Dxt3", bytesPerBlock: 16, blockSize: 4, channels: 4, alpha: true},
+	Dxt5:             {name: "Dxt5", bytesPerBlock: 16, blockSize: 4, channels: 4, alpha: true},
+	BGRX8888:         {name: "BGRX8888", bytesPerBlock: 4, blockSize: 1, channels: 3},
+	BGR565:           {name: "BGR565", bytesPerBlock: 2, blockSize: 1, channels: 3},
+	BGRX5551:         {name: "BGRX5551", bytesPerBlock: 2, blockSize: 1, channels: 3},
+	BGRA4444:         {name: "BGRA4444", bytesPerBlock: 2, blockSize: 1, channels: 4, alpha: true},
+	Dxt1OneBitAlpha:  {name: "Dxt1OneBitAlpha", bytesPerBlock: 8, blockSize: 4, channels: 4, alpha: true},
+	BGRA5551:         {name: "BGRA5551", bytesPerBlock: 2, blockSize: 1, channels: 4, alpha: true},
+	UV88:             {name: "UV88", bytesPerBlock: 2, blockSize: 1, channels: 2},
+	UVWQ8888:         {name: "UVWQ8888", bytesPerBlock: 4, blockSize: 1, channels: 4},
+	RGBA16161616F:    {name: "RGBA16161616F", bytesPerBlock: 8, blockSize: 1, channels: 4, alpha: true, float: true},
+	RGBA16161616:     {name: "RGBA16161616", bytesPerBlock: 8, blockSize: 1, channels: 4, alpha: true},
+	UVLX8888:         {name: "UVLX8888", bytesPerBlock: 4, blockSize: 1, channels: 4},
+	R32F:             {name: "R32F", bytesPerBlock: 4, blockSize: 1, channels: 1, float: true},
+	RGB323232F:       {name: "RGB323232F", bytesPerBlock: 12, blockSize: 1, channels: 3, float: true},
+	RGBA32323232F:    {name: "RGBA32323232F", bytesPerBlock: 16, blockSize: 1, channels: 4, alpha: true, float: true},
+	NVDST16:          {name: "NVDST16", bytesPerBlock: 2, blockSize: 1, channels: 1},
+	NVDST24:          {name: "NVDST24", bytesPerBlock: 4, blockSize: 1, channels: 1},
+	NVINTZ:           {name: "NVINTZ", bytesPerBlock: 4, blockSize: 1, channels: 1},
+	NVRAWZ:           {name: "NVRAWZ", bytesPerBlock: 4, blockSize: 1, channels: 1},
+	ATIDST16:         {name: "ATIDST16", bytesPerBlock: 2, blockSize: 1, channels: 1},
+	ATIDST24:         {name: "ATIDST24", bytesPerBlock: 4, blockSize: 1, channels: 1},
+	NVNULL:           {name: "NVNULL", bytesPerBlock: 4, blockSize: 1},
+	ATI2N:            {name: "ATI2N", bytesPerBlock: 16, blockSize: 4, channels: 2},
+	ATI1N:            {name: "ATI1N", bytesPerBlock: 8, blockSize: 4, channels: 1},
+	BC7:              {name: "BC7", bytesPerBlock: 16, blockSize: 4, channels: 4, alpha: true},
+	BC6H:             {name: "BC6H", bytesPerBlock: 16, blockSize: 4, channels: 3, float: true},
+}
+
+// String returns the name of a format, as its constant is named
+func (f Format) String() string {
+	if info, ok := formats[f]; ok {
+		return info.name
+	}
+
+	return fmt.Sprintf("Format(%d)", uint32(f))
+}
+
+// IsKnown returns whether this package describes a format
+func (f Format) IsKnown() bool {
+	_, ok := formats[f]
+	return ok
+}
+
+// BytesPerBlock returns the size of a block in bytes. Uncompressed formats
+// have a block of a single pixel. Returns 0 for unknown formats
+func (f Format) BytesPerBlock() int {
+	return formats[f].bytesPerBlock
+}
+
+// BlockSize returns the width & height of a block in pixels;
+// 4 for block compressed formats, otherwise 1. Returns 0 for unknown formats
+func (f Format) BlockSize() int {
+	return formats[f].blockSize
+}
+
+// IsCompressed returns whether a format is block compressed
+func (f Format) IsCompressed() bool {
+	return formats[f].blockSize > 1
+}
+
+// HasAlpha returns whether a format stores transparency. Bluescreen formats
+// store it as pure blue
+func (f Format) HasAlpha() bool {
+	return formats[f].alpha
+}
+
+// ChannelCount returns the number of channels a format stores
+func (f Format) ChannelCount() int {
+	return formats[f].channels
+}
+
+// IsFloat returns whether a format stores floating point channels
+func (f Format) IsFloat() bool {
+	return formats[f].float
+}
+
+// SurfaceSize returns the size in bytes of a width x height x depth surface.
+// Block compressed formats are stored in whole blocks, so round up to a multiple of
+// the block size. Returns 0 for unknown formats
+func (f Format) SurfaceSize(width int, height int, depth int) int {
+	info := formats[f]
+	if info.blockSize == 0 {
+		return 0
+	}
+	blocksWide := (width + info.blockSize - 1) / info.blockSize
+	blocksHigh := (height + info.blockSize - 1) / info.blockSize
+
+	return blocksWide * blocksHigh * depth * info.bytesPerBlock
+}
+
+// ParseFormat returns the format with a name, ignoring case, underscores & spaces,
+// so "Dxt1OneBitAlpha", "DXT1_ONEBITALPHA" & "IMAGE_FORMAT_DXT1_ONEBITALPHA" are all
+// accepted. Format numbers are accepted too
+func ParseFormat(name string) (Format, error) {
+	if id, err := strconv.ParseUint(name, 10, 32); err == nil && Format(id).IsKnown() {
+		return Format(id), nil
+	}
+
+	normalised := normaliseName(strings.TrimPrefix(strings.ToUpper(name), "IMAGE_FORMAT_"))
+	for f, info := range formats {
+		if normaliseName(info.name) == normalised {
+			return f, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %q", ErrorUnknownFormat, name)
+}
+
+// normaliseName upper cases a name, and removes underscores & spaces
+func normaliseName(name string) string {
+	return strings.NewReplacer("_", "", " ", "").Replace(strings.ToUpper(name))
+}
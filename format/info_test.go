@@ -0,0 +1,104 @@
+package format
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFormat_SurfaceSize(t *testing.T) {
+	tests := []struct {
+		format               Format
+		width, height, depth int
+		expected             int
+	}{
+		{RGBA8888, 8, 4, 1, 128},
+		{RGB888, 3, 3, 2, 54},
+		{I8, 5, 1, 1, 5},
+		{RGBA16161616F, 2, 2, 1, 32},
+		{Dxt1, 4, 4, 1, 8},
+		{Dxt1, 1, 1, 1, 8},
+		{Dxt1, 6, 3, 1, 16},
+		{Dxt1OneBitAlpha, 8, 8, 1, 32},
+		{Dxt3, 5, 5, 1, 64},
+		{Dxt5, 16, 16, 2, 512},
+		{R32F, 4, 4, 1, 64},
+		{RGB323232F, 2, 1, 1, 24},
+		{RGBA32323232F, 2, 2, 1, 64},
+		{NVDST16, 4, 4, 1, 32},
+		{ATI1N, 6, 3, 1, 16},
+		{ATI2N, 8, 8, 1, 64},
+		{BC7, 5, 5, 1, 64},
+		{BC6H, 4, 4, 2, 32},
+		{Format(0xffff), 4, 4, 1, 0},
+	}
+
+	for _, tt := range tests {
+		if size := tt.format.SurfaceSize(tt.width, tt.height, tt.depth); size != tt.expected {
+			t.Errorf("%s %dx%dx%d: expected %d bytes, got %d", tt.format, tt.width, tt.height, tt.depth, tt.expected, size)
+		}
+	}
+}
+
+func TestFormat_Metadata(t *testing.T) {
+	for _, f := range []Format{Dxt1, Dxt1OneBitAlpha, Dxt3, Dxt5, ATI1N, ATI2N, BC7, BC6H} {
+		if !f.IsCompressed() || f.BlockSize() != 4 {
+			t.Errorf("%s: expected a compressed format of 4x4 blocks", f)
+		}
+	}
+	if RGBA8888.IsCompressed() || RGBA8888.BlockSize() != 1 || RGBA8888.BytesPerBlock() != 4 {
+		t.Error("RGBA8888: expected an uncompressed format of 4 byte pixels")
+	}
+
+	if !Dxt5.HasAlpha() || !Dxt1OneBitAlpha.HasAlpha() || !BGRA5551.HasAlpha() || !RGB888BLUESCREEN.HasAlpha() {
+		t.Error("expected formats with transparency to have alpha")
+	}
+	if Dxt1.HasAlpha() || BGRX8888.HasAlpha() || RGB565.HasAlpha() {
+		t.Error("expected opaque formats to have no alpha")
+	}
+
+	if I8.ChannelCount() != 1 || IA88.ChannelCount() != 2 || BGR565.ChannelCount() != 3 || UVWQ8888.ChannelCount() != 4 {
+		t.Error("unexpected channel counts")
+	}
+	if !RGBA16161616F.IsFloat() || !BC6H.IsFloat() || RGBA16161616.IsFloat() || BC7.IsFloat() {
+		t.Error("unexpected floating point formats")
+	}
+
+	unknown := Format(0xffff)
+	if unknown.IsKnown() || unknown.BytesPerBlock() != 0 || unknown.String() != "Format(65535)" {
+		t.Errorf("unexpected metadata for an unknown format: %s", unknown)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := map[string]Format{
+		"RGBA8888":                       RGBA8888,
+		"dxt5":                           Dxt5,
+		"Dxt1OneBitAlpha":                Dxt1OneBitAlpha,
+		"DXT1_ONEBITALPHA":               Dxt1OneBitAlpha,
+		"IMAGE_FORMAT_RGB888_BLUESCREEN": RGB888BLUESCREEN,
+		"13":                             Dxt1,
+		"NV_DST16":                       NVDST16,
+		"IMAGE_FORMAT_ATI2N":             ATI2N,
+	}
+	for name, expected := range tests {
+		f, err := ParseFormat(name)
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+		if f != expected {
+			t.Errorf("%s: expected %s, got %s", name, expected, f)
+		}
+	}
+
+	for f := range formats {
+		if parsed, err := ParseFormat(f.String()); err != nil || parsed != f {
+			t.Errorf("%s: does not parse as its own name", f)
+		}
+	}
+
+	for _, name := range []string{"", "Dxt2", "99999"} {
+		if _, err := ParseFormat(name); !errors.Is(err, ErrorUnknownFormat) {
+			t.Errorf("%q: expected %v, got %v", name, ErrorUnknownFormat, err)
+		}
+	}
+}
@@ -58,4 +58,33 @@ const (
 	RGBA16161616 = Format(25)
 	// UVLX8888 UVLX (8bytes)
 	UVLX8888 = Format(26)
+	// R32F R (4bytes) as a float
+	R32F = Format(27)
+	// RGB323232F RGB (12bytes) as floats
+	RGB323232F = Format(28)
+	// RGBA32323232F RGBA (16bytes) as floats
+	RGBA32323232F = Format(29)
+	// NVDST16 nVidia 16 bit depth (2bytes)
+	NVDST16 = Format(30)
+	// NVDST24 nVidia 24 bit depth (4bytes)
+	NVDST24 = Format(31)
+	// NVINTZ nVidia depth, readable as a texture (4bytes)
+	NVINTZ = Format(32)
+	// NVRAWZ nVidia depth, readable as a texture (4bytes)
+	NVRAWZ = Format(33)
+	// ATIDST16 ATI 16 bit depth (2bytes)
+	ATIDST16 = Format(34)
+	// ATIDST24 ATI 24 bit depth (4bytes)
+	ATIDST24 = Format(35)
+	// NVNULL nVidia render target without colour (4bytes)
+	NVNULL = Format(36)
+	// ATI2N two channel normal map compression, also known as BC5 (16bytes per 4x4 block)
+	ATI2N = Format(37)
+	// ATI1N single channel compression, also known as BC4 (8bytes per 4x4 block)
+	ATI1N = Format(38)
+	// BC7 high quality RGBA compression, used by newer Source branches (16bytes per 4x4 block)
+	BC7 = Format(70)
+	// BC6H unsigned half float RGB compression for HDR, used by newer Source branches
+	// (16bytes per 4x4 block)
+	BC6H = Format(71)
 )
@@ -0,0 +1,184 @@
+package vtf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/galaco/vtf/format"
+)
+
+// fuzzSeedCorpus generates valid textures of every version & layout, then
+// corrupts their headers, resource directories & lengths with edge case values
+func fuzzSeedCorpus(tb testing.TB) [][]byte {
+	var textures []*Vtf
+	for version := uint32(0); version <= 5; version++ {
+		textures = append(textures, newTestVtf(version, 1), newTestVtf(version, 3))
+	}
+
+	cubemap := newTestVtf(5, 2).Header()
+	cubemap.Flags |= FlagEnvironmentMap
+	textures = append(textures, newTestVtfFromHeader(cubemap))
+
+	volume := newTestVtf(3, 1).Header()
+	volume.Depth = 4
+	textures = append(textures, newTestVtfFromHeader(volume))
+
+	for _, f := range []format.Format{format.Dxt5, format.BC7, format.BC6H} {
+		compressed := newTestVtf(4, 1).Header()
+		compressed.Width, compressed.Height = 6, 3
+		compressed.MipmapCount = 3
+		compressed.HighResImageFormat = uint32(f)
+		textures = append(textures, newTestVtfFromHeader(compressed))
+	}
+
+	noLowRes := newTestVtf(4, 1).Header()
+	noLowRes.LowResImageFormat = noLowResImageFormat
+	textures = append(textures, newTestVtfFromHeader(noLowRes))
+
+	withResources := newTestVtf(5, 1)
+	withResources.SetResource(Resource{Tag: ResourceTagKeyValues, Data: []byte("key value")})
+	withResources.SetResource(Resource{Tag: ResourceTagCRC, Flags: ResourceFlagNoDataChunk, Data: []byte{1, 2, 3, 4}})
+	textures = append(textures, withResources)
+
+	var valid [][]byte
+	for _, texture := range textures {
+		var buf bytes.Buffer
+		if err := WriteToStream(&buf, texture); err != nil {
+			tb.Fatal(err)
+		}
+		valid = append(valid, buf.Bytes())
+	}
+	if sample, err := os.ReadFile("samples/read/test.vtf"); err == nil {
+		valid = append(valid, sample)
+	}
+
+	corpus := append([][]byte{nil, []byte(vtfSignature)}, valid...)
+	patch := func(data []byte, offset int, value []byte) {
+		if offset+len(value) > len(data) {
+			return
+		}
+		patched := append([]byte(nil), data...)
+		copy(patched[offset:], value)
+		corpus = append(corpus, patched)
+	}
+	u8 := func(value uint8) []byte { return []byte{value} }
+	u16 := func(value uint16) []byte { return binary.LittleEndian.AppendUint16(nil, value) }
+	u32 := func(value uint32) []byte { return binary.LittleEndian.AppendUint32(nil, value) }
+
+	for _, data := range valid {
+		for _, length := range []int{4, 15, 16, 56, 63, 72, 80, len(data) / 2, len(data) - 1} {
+			if length < len(data) {
+				corpus = append(corpus, data[:length])
+			}
+		}
+
+		for _, value := range []uint32{0, 72, 0x7fffffff, 0xffffffff} {
+			for _, field := range []string{"HeaderSize", "HighResImageFormat", "LowResImageFormat", "NumResource"} {
+				patch(data, headerOffset(field), u32(value))
+			}
+		}
+		for _, value := range []uint16{0, 0x7fff, 0xffff} {
+			for _, field := range []string{"Width", "Height", "Frames", "Depth"} {
+				patch(data, headerOffset(field), u16(value))
+			}
+		}
+		for _, value := range []uint8{0, 16, 127, 128, 255} {
+			for _, field := range []string{"MipmapCount", "LowResImageWidth", "LowResImageHeight"} {
+				patch(data, headerOffset(field), u8(value))
+			}
+		}
+
+		// Resource entries & the lengths of their data
+		numResource := binary.LittleEndian.Uint32(data[headerOffset("NumResource"):])
+		for idx := 0; idx < int(numResource); idx++ {
+			entry := headerSize73 + idx*resourceEntrySize
+			if entry+resourceEntrySize > len(data) {
+				break
+			}
+			patch(data, entry+3, u8(data[entry+3]^ResourceFlagNoDataChunk))
+			for _, value := range []uint32{0, 0x7fffffff, 0xffffffff, uint32(len(data)), uint32(len(data) - 4)} {
+				patch(data, entry+4, u32(value))
+			}
+			if offset := int(binary.LittleEndian.Uint32(data[entry+4:])); data[entry+3]&ResourceFlagNoDataChunk == 0 && offset+4 <= len(data) {
+				patch(data, offset, u32(0xffffffff))
+			}
+		}
+	}
+
+	return corpus
+}
+
+// exerciseVtf accesses everything that may be read or decoded from a vtf
+func exerciseVtf(v *Vtf) {
+	_, _ = v.LowResImage()
+	_ = v.HighResImageData()
+	header := v.Header()
+	for mipmap := 0; mipmap < v.LoadedMipmapCount(); mipmap++ {
+		for frame := 0; frame < int(header.Frames); frame++ {
+			_, _ = v.DecodeMipmap(mipmap, frame)
+		}
+	}
+}
+
+func FuzzReadFromStream(f *testing.F) {
+	for _, data := range fuzzSeedCorpus(f) {
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if v, err := ReadFromStream(bytes.NewReader(data)); err == nil {
+			exerciseVtf(v)
+		}
+		if v, err := ReadFromStream(bytes.NewReader(data), WithLenient()); err == nil {
+			exerciseVtf(v)
+		}
+	})
+}
+
+func FuzzReadFromReaderAt(f *testing.F) {
+	for _, data := range fuzzSeedCorpus(f) {
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if v, err := ReadFromReaderAt(bytes.NewReader(data), int64(len(data))); err == nil {
+			exerciseVtf(v)
+		}
+		if v, err := ReadFromReaderAt(bytes.NewReader(data), int64(len(data)), WithLenient()); err == nil {
+			exerciseVtf(v)
+		}
+	})
+}
+
+func FuzzReadHeaderFromStream(f *testing.F) {
+	for _, data := range fuzzSeedCorpus(f) {
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = ReadHeaderFromStream(bytes.NewReader(data))
+	})
+}
+
+func FuzzStreamReader(f *testing.F) {
+	for _, data := range fuzzSeedCorpus(f) {
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, options := range [][]ReadOption{nil, {WithLenient()}} {
+			streamReader, err := NewStreamReader(bytes.NewReader(data), options...)
+			if err != nil {
+				continue
+			}
+			_ = streamReader.ReadSurfaces(func(mipmap int, frame int, face int, slice int, data []byte) error {
+				return nil
+			})
+		}
+	})
+}
+
+func TestReadFromReaderAt_NegativeSize(t *testing.T) {
+	if _, err := ReadFromReaderAt(bytes.NewReader(nil), -1); !errors.Is(err, ErrorInvalidHeaderSize) {
+		t.Errorf("expected %v, got %v", ErrorInvalidHeaderSize, err)
+	}
+}
@@ -58,3 +58,50 @@ type Header73 struct {
 	// NumResource is number of resources this vtf has
 	NumResource uint32
 }
+
+// noSphereMapFirstFrame is the FirstFrame value that marks a pre-7.5 environment
+// map as having no spheremap face
+const noSphereMapFirstFrame = 0xffff
+
+// noLowResImageFormat is the LowResImageFormat of a texture without a thumbnail
+const noLowResImageFormat = 0xffffffff
+
+// SliceCount returns the depth of the largest mipmap; the number of Z slices
+// it stores. Textures that are not volume textures have a single slice.
+func (header Header) SliceCount() int {
+	if (header.Version[0] == 7 && header.Version[1] < 2) || header.Depth == 0 {
+		return 1
+	}
+
+	return int(header.Depth)
+}
+
+// HasLowResImage returns whether this texture has a low resolution thumbnail.
+// Textures without one have a format of 0xFFFFFFFF, or a size of 0x0.
+func (header Header) HasLowResImage() bool {
+	return header.LowResImageFormat != noLowResImageFormat &&
+		header.LowResImageWidth > 0 &&
+		header.LowResImageHeight > 0
+}
+
+// IsCubemap returns whether this texture is an environment map (cubemap)
+func (header Header) IsCubemap() bool {
+	return header.Flags&FlagEnvironmentMap != 0
+}
+
+// FaceCount returns the number of faces stored for every frame of every mipmap.
+// Cubemaps have 6 faces. Versions before 7.5 also store a 7th spheremap face,
+// unless FirstFrame is 0xffff. All other textures have a single face.
+func (header Header) FaceCount() int {
+	if !header.IsCubemap() {
+		return 1
+	}
+
+	if header.Version[0] == 7 &&
+		header.Version[1] < 5 &&
+		header.FirstFrame != noSphereMapFirstFrame {
+		return CubemapFaceCount + 1
+	}
+
+	return CubemapFaceCount
+}
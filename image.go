@@ -0,0 +1,57 @@
+package vtf
+
+import (
+	"image"
+	"image/color"
+	"io"
+
+	"github.com/galaco/vtf/format"
+)
+
+func init() {
+	image.RegisterFormat("vtf", vtfSignature, Decode, DecodeConfig)
+}
+
+// Decode reads a vtf from r and returns the largest mipmap of
+// the first frame as an image.
+// Registered with the image package, so image.Decode understands vtf
+func Decode(r io.Reader) (image.Image, error) {
+	v, err := ReadFromStream(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.DecodeMipmap(v.LoadedMipmapCount()-1, 0)
+}
+
+// DecodeConfig returns the colour model and dimensions of a vtf
+// without reading any image data.
+// Registered with the image package, so image.DecodeConfig understands vtf
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	// Only the header is needed, so avoid reading the whole stream
+	header, err := ReadHeaderFromStream(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	return image.Config{
+		ColorModel: colorModelForFormat(format.Format(header.HighResImageFormat)),
+		Width:      int(header.Width),
+		Height:     int(header.Height),
+	}, nil
+}
+
+// colorModelForFormat returns the colour model of the image type
+// DecodeImageData produces for a format
+func colorModelForFormat(storedFormat format.Format) color.Model {
+	switch storedFormat {
+	case format.I8, format.ATI1N:
+		return color.GrayModel
+	case format.A8:
+		return color.AlphaModel
+	case format.RGBA16161616, format.BC6H:
+		return color.NRGBA64Model
+	}
+
+	return color.NRGBAModel
+}
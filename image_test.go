@@ -0,0 +1,58 @@
+package vtf
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"os"
+	"testing"
+)
+
+func TestImageDecode(t *testing.T) {
+	f, err := os.Open("samples/read/test.vtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	img, name, err := image.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "vtf" {
+		t.Errorf("expected format name vtf, got %s", name)
+	}
+	if img.Bounds() != image.Rect(0, 0, 512, 128) {
+		t.Errorf("unexpected bounds %v", img.Bounds())
+	}
+}
+
+func TestImageDecodeConfig(t *testing.T) {
+	f, err := os.Open("samples/read/test.vtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	config, name, err := image.DecodeConfig(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "vtf" {
+		t.Errorf("expected format name vtf, got %s", name)
+	}
+	if config.Width != 512 || config.Height != 128 {
+		t.Errorf("unexpected dimensions %dx%d", config.Width, config.Height)
+	}
+	if config.ColorModel != color.NRGBAModel {
+		t.Errorf("unexpected colour model")
+	}
+}
+
+func TestImageDecodeConfig_NotVtf(t *testing.T) {
+	_, _, err := image.DecodeConfig(bytes.NewReader([]byte("not a vtf file")))
+	if !errors.Is(err, image.ErrFormat) {
+		t.Errorf("expected %v, got %v", image.ErrFormat, err)
+	}
+}
@@ -0,0 +1,309 @@
+package internal
+
+import "strconv"
+
+// bc6h endpoint fields; endpoint W, X, Y & Z of each channel.
+// The first region is interpolated between W & X, the second between Y & Z
+const (
+	bc6hRW = iota
+	bc6hGW
+	bc6hBW
+	bc6hRX
+	bc6hGX
+	bc6hBX
+	bc6hRY
+	bc6hGY
+	bc6hBY
+	bc6hRZ
+	bc6hGZ
+	bc6hBZ
+)
+
+// bc6hBitRun is a run of consecutive block bits, stored in a field starting at bit shift
+type bc6hBitRun struct {
+	field int
+	shift int
+	count int
+}
+
+// bc6hMode describes one of the 14 BC6H block modes
+type bc6hMode struct {
+	// transformed endpoints X, Y & Z are stored as deltas from W
+	transformed  bool
+	endpointBits int
+	// deltaBits are the bits of each channel of X, Y & Z
+	deltaBits [3]int
+	// layout is where each endpoint bit is stored, following the mode bits
+	layout []bc6hBitRun
+}
+
+// bc6hModes describes every BC6H mode by its mode bits. Modes 0 & 1 have 2 mode bits,
+// all others 5. Modes whose low 2 bits are 3 have a single region
+var bc6hModes = map[int]bc6hMode{
+	0x00: {
+		transformed: true, endpointBits: 10, deltaBits: [3]int{5, 5, 5},
+		layout: []bc6hBitRun{
+			{bc6hGY, 4, 1}, {bc6hBY, 4, 1}, {bc6hBZ, 4, 1}, {bc6hRW, 0, 10}, {bc6hGW, 0, 10}, {bc6hBW, 0, 10},
+			{bc6hRX, 0, 5}, {bc6hGZ, 4, 1}, {bc6hGY, 0, 4}, {bc6hGX, 0, 5}, {bc6hBZ, 0, 1}, {bc6hGZ, 0, 4},
+			{bc6hBX, 0, 5}, {bc6hBZ, 1, 1}, {bc6hBY, 0, 4}, {bc6hRY, 0, 5}, {bc6hBZ, 2, 1}, {bc6hRZ, 0, 5},
+			{bc6hBZ, 3, 1},
+		},
+	},
+	0x01: {
+		transformed: true, endpointBits: 7, deltaBits: [3]int{6, 6, 6},
+		layout: []bc6hBitRun{
+			{bc6hGY, 5, 1}, {bc6hGZ, 4, 1}, {bc6hGZ, 5, 1}, {bc6hRW, 0, 7}, {bc6hBZ, 0, 1}, {bc6hBZ, 1, 1},
+			{bc6hBY, 4, 1}, {bc6hGW, 0, 7}, {bc6hBY, 5, 1}, {bc6hBZ, 2, 1}, {bc6hGY, 4, 1}, {bc6hBW, 0, 7},
+			{bc6hBZ, 3, 1}, {bc6hBZ, 5, 1}, {bc6hBZ, 4, 1}, {bc6hRX, 0, 6}, {bc6hGY, 0, 4}, {bc6hGX, 0, 6},
+			{bc6hGZ, 0, 4}, {bc6hBX, 0, 6}, {bc6hBY, 0, 4}, {bc6hRY, 0, 6}, {bc6hRZ, 0, 6},
+		},
+	},
+	0x02: {
+		transformed: true, endpointBits: 11, deltaBits: [3]int{5, 4, 4},
+		layout: []bc6hBitRun{
+			{bc6hRW, 0, 10}, {bc6hGW, 0, 10}, {bc6hBW, 0, 10}, {bc6hRX, 0, 5}, {bc6hRW, 10, 1}, {bc6hGY, 0, 4},
+			{bc6hGX, 0, 4}, {bc6hGW, 10, 1}, {bc6hBZ, 0, 1}, {bc6hGZ, 0, 4}, {bc6hBX, 0, 4}, {bc6hBW, 10, 1},
+			{bc6hBZ, 1, 1}, {bc6hBY, 0, 4}, {bc6hRY, 0, 5}, {bc6hBZ, 2, 1}, {bc6hRZ, 0, 5}, {bc6hBZ, 3, 1},
+		},
+	},
+	0x06: {
+		transformed: true, endpointBits: 11, deltaBits: [3]int{4, 5, 4},
+		layout: []bc6hBitRun{
+			{bc6hRW, 0, 10}, {bc6hGW, 0, 10}, {bc6hBW, 0, 10}, {bc6hRX, 0, 4}, {bc6hRW, 10, 1}, {bc6hGZ, 4, 1},
+			{bc6hGY, 0, 4}, {bc6hGX, 0, 5}, {bc6hGW, 10, 1}, {bc6hGZ, 0, 4}, {bc6hBX, 0, 4}, {bc6hBW, 10, 1},
+			{bc6hBZ, 1, 1}, {bc6hBY, 0, 4}, {bc6hRY, 0, 4}, {bc6hBZ, 0, 1}, {bc6hBZ, 2, 1}, {bc6hRZ, 0, 4},
+			{bc6hGY, 4, 1}, {bc6hBZ, 3, 1},
+		},
+	},
+	0x0a: {
+		transformed: true, endpointBits: 11, deltaBits: [3]int{4, 4, 5},
+		layout: []bc6hBitRun{
+			{bc6hRW, 0, 10}, {bc6hGW, 0, 10}, {bc6hBW, 0, 10}, {bc6hRX, 0, 4}, {bc6hRW, 10, 1}, {bc6hBY, 4, 1},
+			{bc6hGY, 0, 4}, {bc6hGX, 0, 4}, {bc6hGW, 10, 1}, {bc6hBZ, 0, 1}, {bc6hGZ, 0, 4}, {bc6hBX, 0, 5},
+			{bc6hBW, 10, 1}, {bc6hBY, 0, 4}, {bc6hRY, 0, 4}, {bc6hBZ, 1, 1}, {bc6hBZ, 2, 1}, {bc6hRZ, 0, 4},
+			{bc6hBZ, 4, 1}, {bc6hBZ, 3, 1},
+		},
+	},
+	0x0e: {
+		transformed: true, endpointBits: 9, deltaBits: [3]int{5, 5, 5},
+		layout: []bc6hBitRun{
+			{bc6hRW, 0, 9}, {bc6hBY, 4, 1}, {bc6hGW, 0, 9}, {bc6hGY, 4, 1}, {bc6hBW, 0, 9}, {bc6hBZ, 4, 1},
+			{bc6hRX, 0, 5}, {bc6hGZ, 4, 1}, {bc6hGY, 0, 4}, {bc6hGX, 0, 5}, {bc6hBZ, 0, 1}, {bc6hGZ, 0, 4},
+			{bc6hBX, 0, 5}, {bc6hBZ, 1, 1}, {bc6hBY, 0, 4}, {bc6hRY, 0, 5}, {bc6hBZ, 2, 1}, {bc6hRZ, 0, 5},
+			{bc6hBZ, 3, 1},
+		},
+	},
+	0x12: {
+		transformed: true, endpointBits: 8, deltaBits: [3]int{6, 5, 5},
+		layout: []bc6hBitRun{
+			{bc6hRW, 0, 8}, {bc6hGZ, 4, 1}, {bc6hBY, 4, 1}, {bc6hGW, 0, 8}, {bc6hBZ, 2, 1}, {bc6hGY, 4, 1},
+			{bc6hBW, 0, 8}, {bc6hBZ, 3, 1}, {bc6hBZ, 4, 1}, {bc6hRX, 0, 6}, {bc6hGY, 0, 4}, {bc6hGX, 0, 5},
+			{bc6hBZ, 0, 1}, {bc6hGZ, 0, 4}, {bc6hBX, 0, 5}, {bc6hBZ, 1, 1}, {bc6hBY, 0, 4}, {bc6hRY, 0, 6},
+			{bc6hRZ, 0, 6},
+		},
+	},
+	0x16: {
+		transformed: true, endpointBits: 8, deltaBits: [3]int{5, 6, 5},
+		layout: []bc6hBitRun{
+			{bc6hRW, 0, 8}, {bc6hBZ, 0, 1}, {bc6hBY, 4, 1}, {bc6hGW, 0, 8}, {bc6hGY, 5, 1}, {bc6hGY, 4, 1},
+			{bc6hBW, 0, 8}, {bc6hGZ, 5, 1}, {bc6hBZ, 4, 1}, {bc6hRX, 0, 5}, {bc6hGZ, 4, 1}, {bc6hGY, 0, 4},
+			{bc6hGX, 0, 6}, {bc6hGZ, 0, 4}, {bc6hBX, 0, 5}, {bc6hBZ, 1, 1}, {bc6hBY, 0, 4}, {bc6hRY, 0, 5},
+			{bc6hBZ, 2, 1}, {bc6hRZ, 0, 5}, {bc6hBZ, 3, 1},
+		},
+	},
+	0x1a: {
+		transformed: true, endpointBits: 8, deltaBits: [3]int{5, 5, 6},
+		layout: []bc6hBitRun{
+			{bc6hRW, 0, 8}, {bc6hBZ, 1, 1}, {bc6hBY, 4, 1}, {bc6hGW, 0, 8}, {bc6hBY, 5, 1}, {bc6hGY, 4, 1},
+			{bc6hBW, 0, 8}, {bc6hBZ, 5, 1}, {bc6hBZ, 4, 1}, {bc6hRX, 0, 5}, {bc6hGZ, 4, 1}, {bc6hGY, 0, 4},
+			{bc6hGX, 0, 5}, {bc6hBZ, 0, 1}, {bc6hGZ, 0, 4}, {bc6hBX, 0, 6}, {bc6hBY, 0, 4}, {bc6hRY, 0, 5},
+			{bc6hBZ, 2, 1}, {bc6hRZ, 0, 5}, {bc6hBZ, 3, 1},
+		},
+	},
+	0x1e: {
+		transformed: false, endpointBits: 6, deltaBits: [3]int{6, 6, 6},
+		layout: []bc6hBitRun{
+			{bc6hRW, 0, 6}, {bc6hGZ, 4, 1}, {bc6hBZ, 0, 1}, {bc6hBZ, 1, 1}, {bc6hBY, 4, 1}, {bc6hGW, 0, 6},
+			{bc6hGY, 5, 1}, {bc6hBY, 5, 1}, {bc6hBZ, 2, 1}, {bc6hGY, 4, 1}, {bc6hBW, 0, 6}, {bc6hGZ, 5, 1},
+			{bc6hBZ, 3, 1}, {bc6hBZ, 5, 1}, {bc6hBZ, 4, 1}, {bc6hRX, 0, 6}, {bc6hGY, 0, 4}, {bc6hGX, 0, 6},
+			{bc6hGZ, 0, 4}, {bc6hBX, 0, 6}, {bc6hBY, 0, 4}, {bc6hRY, 0, 6}, {bc6hRZ, 0, 6},
+		},
+	},
+	0x03: {
+		transformed: false, endpointBits: 10, deltaBits: [3]int{10, 10, 10},
+		layout: []bc6hBitRun{
+			{bc6hRW, 0, 10}, {bc6hGW, 0, 10}, {bc6hBW, 0, 10}, {bc6hRX, 0, 10}, {bc6hGX, 0, 10}, {bc6hBX, 0, 10},
+		},
+	},
+	0x07: {
+		transformed: true, endpointBits: 11, deltaBits: [3]int{9, 9, 9},
+		layout: []bc6hBitRun{
+			{bc6hRW, 0, 10}, {bc6hGW, 0, 10}, {bc6hBW, 0, 10}, {bc6hRX, 0, 9}, {bc6hRW, 10, 1}, {bc6hGX, 0, 9},
+			{bc6hGW, 10, 1}, {bc6hBX, 0, 9}, {bc6hBW, 10, 1},
+		},
+	},
+	0x0b: {
+		transformed: true, endpointBits: 12, deltaBits: [3]int{8, 8, 8},
+		layout: []bc6hBitRun{
+			{bc6hRW, 0, 10}, {bc6hGW, 0, 10}, {bc6hBW, 0, 10}, {bc6hRX, 0, 8}, {bc6hRW, 11, 1}, {bc6hRW, 10, 1},
+			{bc6hGX, 0, 8}, {bc6hGW, 11, 1}, {bc6hGW, 10, 1}, {bc6hBX, 0, 8}, {bc6hBW, 11, 1}, {bc6hBW, 10, 1},
+		},
+	},
+	0x0f: {
+		transformed: true, endpointBits: 16, deltaBits: [3]int{4, 4, 4},
+		layout: []bc6hBitRun{
+			{bc6hRW, 0, 10}, {bc6hGW, 0, 10}, {bc6hBW, 0, 10}, {bc6hRX, 0, 4}, {bc6hRW, 15, 1}, {bc6hRW, 14, 1},
+			{bc6hRW, 13, 1}, {bc6hRW, 12, 1}, {bc6hRW, 11, 1}, {bc6hRW, 10, 1}, {bc6hGX, 0, 4}, {bc6hGW, 15, 1},
+			{bc6hGW, 14, 1}, {bc6hGW, 13, 1}, {bc6hGW, 12, 1}, {bc6hGW, 11, 1}, {bc6hGW, 10, 1}, {bc6hBX, 0, 4},
+			{bc6hBW, 15, 1}, {bc6hBW, 14, 1}, {bc6hBW, 13, 1}, {bc6hBW, 12, 1}, {bc6hBW, 11, 1}, {bc6hBW, 10, 1},
+		},
+	},
+}
+
+// DecompressBC6H decompresses BC6H data into float RGBA pixels, 4 per pixel row by row.
+// Alpha is always 1. Signed data may hold negative values.
+// Data must contain at least 16 bytes per block.
+func DecompressBC6H(data []byte, width int, height int, signed bool) []float32 {
+	pix := make([]float32, width*height*4)
+	blocksWide, blocksHigh := DxtBlockCount(width, height)
+
+	var pixels [16][3]float32
+	offset := 0
+	for by := 0; by < blocksHigh; by++ {
+		for bx := 0; bx < blocksWide; bx++ {
+			decodeBC6HBlock(data[offset:offset+16], signed, &pixels)
+			offset += 16
+
+			for py := 0; py < dxtBlockDimension; py++ {
+				y := by*dxtBlockDimension + py
+				if y >= height {
+					break
+				}
+				for px := 0; px < dxtBlockDimension; px++ {
+					x := bx*dxtBlockDimension + px
+					if x >= width {
+						break
+					}
+					i := (y*width + x) * 4
+					copy(pix[i:i+3], pixels[py*dxtBlockDimension+px][:])
+					pix[i+3] = 1
+				}
+			}
+		}
+	}
+
+	return pix
+}
+
+// decodeBC6HBlock decodes a single 16 byte BC6H block. Blocks of reserved modes decode as black
+func decodeBC6HBlock(block []byte, signed bool, pixels *[16][3]float32) {
+	bits := newBlockBits(block)
+	modeBits := bits.read(2)
+	if modeBits > 1 {
+		modeBits |= bits.read(3) << 2
+	}
+	mode, ok := bc6hModes[modeBits]
+	if !ok {
+		*pixels = [16][3]float32{}
+		return
+	}
+
+	var endpoints [12]int
+	for _, run := range mode.layout {
+		endpoints[run.field] |= bits.read(run.count) << uint(run.shift)
+	}
+	regions, partition := 1, 0
+	if modeBits&3 != 3 {
+		regions, partition = 2, bits.read(5)
+	}
+
+	if signed {
+		for channel := 0; channel < 3; channel++ {
+			endpoints[channel] = signExtend(endpoints[channel], mode.endpointBits)
+		}
+	}
+	if signed || mode.transformed {
+		for field := 3; field < regions*6; field++ {
+			endpoints[field] = signExtend(endpoints[field], mode.deltaBits[field%3])
+		}
+	}
+	if mode.transformed {
+		mask := 1<<uint(mode.endpointBits) - 1
+		for field := 3; field < regions*6; field++ {
+			endpoints[field] = (endpoints[field%3] + endpoints[field]) & mask
+			if signed {
+				endpoints[field] = signExtend(endpoints[field], mode.endpointBits)
+			}
+		}
+	}
+	for field := 0; field < regions*6; field++ {
+		endpoints[field] = bc6hUnquantise(endpoints[field], mode.endpointBits, signed)
+	}
+
+	indexBits := 4
+	if regions == 2 {
+		indexBits = 3
+	}
+	for i := range pixels {
+		index := bits.read(indexBits - anchorBit(i, regions, partition))
+		region := bc7Subset(i, regions, partition)
+		weight := bcWeights[indexBits][index]
+		for channel := 0; channel < 3; channel++ {
+			e0, e1 := endpoints[region*6+channel], endpoints[region*6+3+channel]
+			pixels[i][channel] = HalfToFloat32(bc6hFinishUnquantise(bcInterpolate(e0, e1, weight), signed))
+		}
+	}
+}
+
+// signExtend sign extends a value of a number of bits
+func signExtend(v int, bits int) int {
+	shift := uint(strconv.IntSize - bits)
+	return v << shift >> shift
+}
+
+// bc6hUnquantise scales an endpoint of a number of bits to 16 bits, or 15 bits plus sign
+func bc6hUnquantise(v int, bits int, signed bool) int {
+	if !signed {
+		switch {
+		case bits >= 15:
+			return v
+		case v == 0:
+			return 0
+		case v == 1<<uint(bits)-1:
+			return 0xffff
+		}
+		return (v<<16 + 0x8000) >> uint(bits)
+	}
+
+	if bits >= 16 {
+		return v
+	}
+	negative := v < 0
+	if negative {
+		v = -v
+	}
+	switch {
+	case v == 0:
+	case v >= 1<<uint(bits-1)-1:
+		v = 0x7fff
+	default:
+		v = (v<<15 + 0x4000) >> uint(bits-1)
+	}
+	if negative {
+		return -v
+	}
+
+	return v
+}
+
+// bc6hFinishUnquantise scales an interpolated value to the bits of a half float
+func bc6hFinishUnquantise(v int, signed bool) uint16 {
+	if !signed {
+		return uint16(v * 31 >> 6)
+	}
+	if v < 0 {
+		return uint16(-v*31>>5) | 0x8000
+	}
+
+	return uint16(v * 31 >> 5)
+}
@@ -0,0 +1,267 @@
+package internal
+
+import (
+	"encoding/binary"
+	"image"
+)
+
+// bc7Mode describes the layout of one of the 8 BC7 block modes
+type bc7Mode struct {
+	subsets            int
+	partitionBits      int
+	rotationBits       int
+	indexSelectionBits int
+	colourBits         int
+	alphaBits          int
+	// endpointPBit stores a low bit for every endpoint, sharedPBit one for both endpoints of a subset
+	endpointPBit       bool
+	sharedPBit         bool
+	indexBits          int
+	secondaryIndexBits int
+}
+
+// bc7Modes describes every BC7 mode; the mode of a block is its number of leading 0 bits
+var bc7Modes = [8]bc7Mode{
+	{subsets: 3, partitionBits: 4, colourBits: 4, endpointPBit: true, indexBits: 3},
+	{subsets: 2, partitionBits: 6, colourBits: 6, sharedPBit: true, indexBits: 3},
+	{subsets: 3, partitionBits: 6, colourBits: 5, indexBits: 2},
+	{subsets: 2, partitionBits: 6, colourBits: 7, endpointPBit: true, indexBits: 2},
+	{subsets: 1, rotationBits: 2, indexSelectionBits: 1, colourBits: 5, alphaBits: 6, indexBits: 2, secondaryIndexBits: 3},
+	{subsets: 1, rotationBits: 2, colourBits: 7, alphaBits: 8, indexBits: 2, secondaryIndexBits: 2},
+	{subsets: 1, colourBits: 7, alphaBits: 7, endpointPBit: true, indexBits: 4},
+	{subsets: 2, partitionBits: 6, colourBits: 5, alphaBits: 5, endpointPBit: true, indexBits: 2},
+}
+
+// bcWeights are the interpolation weights, out of 64, of 2, 3 & 4 bit BC6H & BC7 indices
+var bcWeights = [5][]int{
+	2: {0, 21, 43, 64},
+	3: {0, 9, 18, 27, 37, 46, 55, 64},
+	4: {0, 4, 9, 13, 17, 21, 26, 30, 34, 38, 43, 47, 51, 55, 60, 64},
+}
+
+// bc7Partitions2 are the 2 subset partitions; bit n is set when pixel n is in the second subset.
+// BC6H uses the first 32
+var bc7Partitions2 = [64]uint16{
+	0xcccc, 0x8888, 0xeeee, 0xecc8, 0xc880, 0xfeec, 0xfec8, 0xec80,
+	0xc800, 0xffec, 0xfe80, 0xe800, 0xffe8, 0xff00, 0xfff0, 0xf000,
+	0xf710, 0x008e, 0x7100, 0x08ce, 0x008c, 0x7310, 0x3100, 0x8cce,
+	0x088c, 0x3110, 0x6666, 0x366c, 0x17e8, 0x0ff0, 0x718e, 0x399c,
+	0xaaaa, 0xf0f0, 0x5a5a, 0x33cc, 0x3c3c, 0x55aa, 0x9696, 0xa55a,
+	0x73ce, 0x13c8, 0x324c, 0x3bdc, 0x6996, 0xc33c, 0x9966, 0x0660,
+	0x0272, 0x04e4, 0x4e40, 0x2720, 0xc936, 0x936c, 0x39c6, 0x639c,
+	0x9336, 0x9cc6, 0x817e, 0xe718, 0xccf0, 0x0fcc, 0x7744, 0xee22,
+}
+
+// bc7Partitions3 are the 3 subset partitions; bits 2n & 2n+1 are the subset of pixel n
+var bc7Partitions3 = [64]uint32{
+	0xaa685050, 0x6a5a5040, 0x5a5a4200, 0x5450a0a8, 0xa5a50000, 0xa0a05050, 0x5555a0a0, 0x5a5a5050,
+	0xaa550000, 0xaa555500, 0xaaaa5500, 0x90909090, 0x94949494, 0xa4a4a4a4, 0xa9a59450, 0x2a0a4250,
+	0xa5945040, 0x0a425054, 0xa5a5a500, 0x55a0a0a0, 0xa8a85454, 0x6a6a4040, 0xa4a45000, 0x1a1a0500,
+	0x0050a4a4, 0xaaa59090, 0x14696914, 0x69691400, 0xa08585a0, 0xaa821414, 0x50a4a450, 0x6a5a0200,
+	0xa9a58000, 0x5090a0a8, 0xa8a09050, 0x24242424, 0x00aa5500, 0x24924924, 0x24499224, 0x50a50a50,
+	0x500aa550, 0xaaaa4444, 0x66660000, 0xa5a0a5a0, 0x50a050a0, 0x69286928, 0x44aaaa44, 0x66666600,
+	0xaa444444, 0x54a854a8, 0x95809580, 0x96969600, 0xa85454a8, 0x80959580, 0xaa141414, 0x96960000,
+	0xaaaa1414, 0xa05050a0, 0xa0a5a5a0, 0x96000000, 0x40804080, 0xa9a8a9a8, 0xaaaaaa44, 0x2a4a5254,
+}
+
+// bc7Anchors2 is the anchor pixel of the second subset of each 2 subset partition
+var bc7Anchors2 = [64]int{
+	15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15,
+	15, 2, 8, 2, 2, 8, 8, 15, 2, 8, 2, 2, 8, 8, 2, 2,
+	15, 15, 6, 8, 2, 8, 15, 15, 2, 8, 2, 2, 2, 15, 15, 6,
+	6, 2, 6, 8, 15, 15, 2, 2, 15, 15, 15, 15, 15, 2, 2, 15,
+}
+
+// bc7Anchors3 are the anchor pixels of the second & third subsets of each 3 subset partition
+var bc7Anchors3 = [2][64]int{
+	{
+		3, 3, 15, 15, 8, 3, 15, 15, 8, 8, 6, 6, 6, 5, 3, 3,
+		3, 3, 8, 15, 3, 3, 6, 10, 5, 8, 8, 6, 8, 5, 15, 15,
+		8, 15, 3, 5, 6, 10, 8, 15, 15, 3, 15, 5, 15, 15, 15, 15,
+		3, 15, 5, 5, 5, 8, 5, 10, 5, 10, 8, 13, 15, 12, 3, 3,
+	},
+	{
+		15, 8, 8, 3, 15, 15, 3, 8, 15, 15, 15, 15, 15, 15, 15, 8,
+		15, 8, 15, 3, 15, 8, 15, 8, 3, 15, 6, 10, 15, 15, 10, 8,
+		15, 3, 15, 10, 10, 8, 9, 10, 6, 15, 8, 15, 3, 6, 6, 8,
+		15, 3, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 3, 15, 15, 8,
+	},
+}
+
+// DecompressBC7 decompresses BC7 data into an NRGBA image.
+// Data must contain at least 16 bytes per block.
+func DecompressBC7(data []byte, width int, height int) *image.NRGBA {
+	return decompressDxt(data, width, height, 16, decodeBC7Block)
+}
+
+// decodeBC7Block decodes a single 16 byte BC7 block. Blocks of the reserved
+// mode decode as transparent black
+func decodeBC7Block(block []byte, pixels *[16][4]uint8) {
+	modeIndex := 0
+	for modeIndex < len(bc7Modes) && block[0]&(1<<uint(modeIndex)) == 0 {
+		modeIndex++
+	}
+	if modeIndex == len(bc7Modes) {
+		*pixels = [16][4]uint8{}
+		return
+	}
+	mode := bc7Modes[modeIndex]
+
+	bits := newBlockBits(block)
+	bits.skip(modeIndex + 1)
+	partition := bits.read(mode.partitionBits)
+	rotation := bits.read(mode.rotationBits)
+	indexSelection := bits.read(mode.indexSelectionBits)
+
+	// endpoints of each subset, before their p-bits are appended
+	var endpoints [3][2][4]int
+	for channel := 0; channel < 4; channel++ {
+		channelBits := mode.colourBits
+		if channel == 3 {
+			channelBits = mode.alphaBits
+		}
+		for subset := 0; subset < mode.subsets; subset++ {
+			endpoints[subset][0][channel] = bits.read(channelBits)
+			endpoints[subset][1][channel] = bits.read(channelBits)
+		}
+	}
+
+	colourBits, alphaBits := mode.colourBits, mode.alphaBits
+	if mode.endpointPBit || mode.sharedPBit {
+		for subset := 0; subset < mode.subsets; subset++ {
+			pBits := [2]int{bits.read(1)}
+			if mode.endpointPBit {
+				pBits[1] = bits.read(1)
+			} else {
+				pBits[1] = pBits[0]
+			}
+			for end := 0; end < 2; end++ {
+				for channel := 0; channel < 4; channel++ {
+					endpoints[subset][end][channel] = endpoints[subset][end][channel]<<1 | pBits[end]
+				}
+			}
+		}
+		colourBits++
+		if alphaBits > 0 {
+			alphaBits++
+		}
+	}
+
+	var colours [3][2][4]uint8
+	for subset := 0; subset < mode.subsets; subset++ {
+		for end := 0; end < 2; end++ {
+			for channel := 0; channel < 3; channel++ {
+				colours[subset][end][channel] = expandBits(endpoints[subset][end][channel], colourBits)
+			}
+			colours[subset][end][3] = 255
+			if alphaBits > 0 {
+				colours[subset][end][3] = expandBits(endpoints[subset][end][3], alphaBits)
+			}
+		}
+	}
+
+	var indices, secondaryIndices [16]int
+	for i := range indices {
+		indices[i] = bits.read(mode.indexBits - anchorBit(i, mode.subsets, partition))
+	}
+	if mode.secondaryIndexBits > 0 {
+		for i := range secondaryIndices {
+			secondaryIndices[i] = bits.read(mode.secondaryIndexBits - anchorBit(i, 1, 0))
+		}
+	}
+
+	for i := range pixels {
+		c := &colours[bc7Subset(i, mode.subsets, partition)]
+		colourIndex, colourIndexBits := indices[i], mode.indexBits
+		alphaIndex, alphaIndexBits := colourIndex, colourIndexBits
+		if mode.secondaryIndexBits > 0 {
+			alphaIndex, alphaIndexBits = secondaryIndices[i], mode.secondaryIndexBits
+			if indexSelection == 1 {
+				colourIndex, colourIndexBits, alphaIndex, alphaIndexBits = alphaIndex, alphaIndexBits, colourIndex, colourIndexBits
+			}
+		}
+
+		colourWeight := bcWeights[colourIndexBits][colourIndex]
+		for channel := 0; channel < 3; channel++ {
+			pixels[i][channel] = uint8(bcInterpolate(int(c[0][channel]), int(c[1][channel]), colourWeight))
+		}
+		pixels[i][3] = uint8(bcInterpolate(int(c[0][3]), int(c[1][3]), bcWeights[alphaIndexBits][alphaIndex]))
+
+		// Rotation swaps alpha with another channel, so it gets the most precision
+		if rotation > 0 {
+			pixels[i][rotation-1], pixels[i][3] = pixels[i][3], pixels[i][rotation-1]
+		}
+	}
+}
+
+// bc7Subset returns the subset a pixel belongs to in a partition
+func bc7Subset(pixel int, subsets int, partition int) int {
+	switch subsets {
+	case 2:
+		return int(bc7Partitions2[partition]>>uint(pixel)) & 1
+	case 3:
+		return int(bc7Partitions3[partition]>>(2*uint(pixel))) & 3
+	}
+
+	return 0
+}
+
+// anchorBit returns 1 if a pixel is the anchor of its subset, as anchors
+// are stored with their implicitly 0 most significant index bit omitted
+func anchorBit(pixel int, subsets int, partition int) int {
+	switch {
+	case pixel == 0,
+		subsets == 2 && pixel == bc7Anchors2[partition],
+		subsets == 3 && (pixel == bc7Anchors3[0][partition] || pixel == bc7Anchors3[1][partition]):
+		return 1
+	}
+
+	return 0
+}
+
+// expandBits scales a value of 4 to 8 bits to 8 bits, by replicating its high bits
+func expandBits(v int, bits int) uint8 {
+	return uint8(v<<uint(8-bits) | v>>uint(2*bits-8))
+}
+
+// bcInterpolate blends two endpoints by a weight out of 64
+func bcInterpolate(e0 int, e1 int, weight int) int {
+	return ((64-weight)*e0 + weight*e1 + 32) >> 6
+}
+
+// blockBits reads the bits of a 16 byte block, least significant first
+type blockBits struct {
+	lo  uint64
+	hi  uint64
+	pos uint
+}
+
+// newBlockBits returns a reader of the first 16 bytes of a block
+func newBlockBits(block []byte) blockBits {
+	return blockBits{
+		lo: binary.LittleEndian.Uint64(block[0:8]),
+		hi: binary.LittleEndian.Uint64(block[8:16]),
+	}
+}
+
+// read returns the next count bits
+func (bits *blockBits) read(count int) int {
+	value := 0
+	for i := 0; i < count; i++ {
+		var bit uint64
+		if bits.pos < 64 {
+			bit = bits.lo >> bits.pos
+		} else {
+			bit = bits.hi >> (bits.pos - 64)
+		}
+		value |= int(bit&1) << uint(i)
+		bits.pos++
+	}
+
+	return value
+}
+
+// skip moves past the next count bits
+func (bits *blockBits) skip(count int) {
+	bits.pos += uint(count)
+}
@@ -0,0 +1,244 @@
+package internal
+
+import (
+	"encoding/binary"
+	"image"
+	"math"
+)
+
+// bc7RefineIterations is how many times cluster fit quality refines BC7 endpoints
+const bc7RefineIterations = 2
+
+// CompressBC7 compresses an image into BC7 blocks. Every block uses mode 6; a single
+// subset of 7 bit RGBA endpoints with a p-bit each, and 4 bit indices.
+// Cluster fit quality refines the range fit endpoints by least squares.
+func CompressBC7(img *image.NRGBA, quality DxtQuality) []byte {
+	return compressDxt(img, 16, func(pixels *[16][4]uint8, dst []byte) {
+		compressBC7Block(pixels, quality, dst)
+	})
+}
+
+// compressBC7Block writes a single mode 6 BC7 block
+func compressBC7Block(pixels *[16][4]uint8, quality DxtQuality, dst []byte) {
+	opaque := true
+	for i := range pixels {
+		opaque = opaque && pixels[i][3] == 255
+	}
+
+	start, end := bc7RangeFit(pixels)
+	e0, e1, indices, best := bc7FitEndpoints(pixels, start, end, opaque)
+
+	if quality == DxtQualityClusterFit {
+		for iteration := 0; iteration < bc7RefineIterations; iteration++ {
+			start, end, ok := bc7LeastSquares(pixels, &indices)
+			if !ok {
+				break
+			}
+			r0, r1, refined, total := bc7FitEndpoints(pixels, start, end, opaque)
+			if total >= best {
+				break
+			}
+			e0, e1, indices, best = r0, r1, refined, total
+		}
+	}
+
+	// The anchor index is stored without its most significant bit, which must be 0
+	if indices[0] >= 8 {
+		e0, e1 = e1, e0
+		for i := range indices {
+			indices[i] = 15 - indices[i]
+		}
+	}
+
+	var bits blockBits
+	bits.write(1<<6, 7)
+	for channel := 0; channel < 4; channel++ {
+		bits.write(int(e0[channel]>>1), 7)
+		bits.write(int(e1[channel]>>1), 7)
+	}
+	bits.write(int(e0[0]&1), 1)
+	bits.write(int(e1[0]&1), 1)
+	for i := range indices {
+		bits.write(indices[i], 4-anchorBit(i, 1, 0))
+	}
+	binary.LittleEndian.PutUint64(dst[0:8], bits.lo)
+	binary.LittleEndian.PutUint64(dst[8:16], bits.hi)
+}
+
+// bc7RangeFit returns the extremes of a block along its principal axis
+func bc7RangeFit(pixels *[16][4]uint8) ([4]float64, [4]float64) {
+	var mean [4]float64
+	for i := range pixels {
+		for c := 0; c < 4; c++ {
+			mean[c] += float64(pixels[i][c]) / 16
+		}
+	}
+
+	var covariance [4][4]float64
+	for i := range pixels {
+		var d [4]float64
+		for c := 0; c < 4; c++ {
+			d[c] = float64(pixels[i][c]) - mean[c]
+		}
+		for a := 0; a < 4; a++ {
+			for b := 0; b < 4; b++ {
+				covariance[a][b] += d[a] * d[b]
+			}
+		}
+	}
+
+	// Start from the covariance row of the channel that varies most. A fixed start can be
+	// orthogonal to the axis, as when channels vary in opposite directions
+	largest := 0
+	for a := 1; a < 4; a++ {
+		if covariance[a][a] > covariance[largest][largest] {
+			largest = a
+		}
+	}
+	axis := [4]float64{1, 1, 1, 1}
+	if covariance[largest][largest] > 0 {
+		axis = covariance[largest]
+	}
+	for iteration := 0; iteration < 8; iteration++ {
+		var next [4]float64
+		for a := 0; a < 4; a++ {
+			next[a] = dot4(covariance[a], axis)
+		}
+		length := math.Sqrt(dot4(next, next))
+		if length < 1e-9 {
+			break
+		}
+		for a := 0; a < 4; a++ {
+			axis[a] = next[a] / length
+		}
+	}
+
+	minimum, maximum := math.Inf(1), math.Inf(-1)
+	for i := range pixels {
+		var d [4]float64
+		for c := 0; c < 4; c++ {
+			d[c] = float64(pixels[i][c]) - mean[c]
+		}
+		t := dot4(d, axis)
+		minimum = math.Min(minimum, t)
+		maximum = math.Max(maximum, t)
+	}
+
+	var start, end [4]float64
+	for c := 0; c < 4; c++ {
+		start[c] = mean[c] + axis[c]*minimum
+		end[c] = mean[c] + axis[c]*maximum
+	}
+
+	return start, end
+}
+
+// bc7LeastSquares returns the endpoints with least squared error for a set of indices.
+// Returns false when every pixel has the same weight, as the endpoints are then undetermined
+func bc7LeastSquares(pixels *[16][4]uint8, indices *[16]int) ([4]float64, [4]float64, bool) {
+	var aa, ab, bb float64
+	var ax, bx [4]float64
+	for i := range pixels {
+		b := float64(bcWeights[4][indices[i]]) / 64
+		a := 1 - b
+		aa += a * a
+		ab += a * b
+		bb += b * b
+		for c := 0; c < 4; c++ {
+			ax[c] += a * float64(pixels[i][c])
+			bx[c] += b * float64(pixels[i][c])
+		}
+	}
+
+	determinant := aa*bb - ab*ab
+	if math.Abs(determinant) < 1e-9 {
+		return ax, bx, false
+	}
+
+	var start, end [4]float64
+	for c := 0; c < 4; c++ {
+		start[c] = (ax[c]*bb - bx[c]*ab) / determinant
+		end[c] = (bx[c]*aa - ax[c]*ab) / determinant
+	}
+
+	return start, end, true
+}
+
+// bc7FitEndpoints quantises a pair of endpoints, returning them with their indices & total
+// squared error. Every pair of p-bits is tried, except in opaque blocks; the 8 bit value of each
+// channel is its 7 bits followed by the endpoint's p-bit, so only a p-bit of 1 keeps alpha 255
+func bc7FitEndpoints(pixels *[16][4]uint8, start [4]float64, end [4]float64, opaque bool) ([4]uint8, [4]uint8, [16]int, int) {
+	var e0, e1 [4]uint8
+	var indices [16]int
+	best := math.MaxInt64
+	for pBits := 0; pBits < 4; pBits++ {
+		if opaque && pBits != 3 {
+			continue
+		}
+		c0, c1 := quantiseBC7Endpoint(start, pBits&1), quantiseBC7Endpoint(end, pBits>>1)
+		candidate, total := bc7Indices(pixels, c0, c1)
+		if total < best {
+			e0, e1, indices, best = c0, c1, candidate, total
+		}
+	}
+
+	return e0, e1, indices, best
+}
+
+// quantiseBC7Endpoint returns the nearest mode 6 endpoint to a colour with a p-bit
+func quantiseBC7Endpoint(c [4]float64, pBit int) [4]uint8 {
+	var endpoint [4]uint8
+	for channel := 0; channel < 4; channel++ {
+		endpoint[channel] = uint8(clampRound((c[channel]-float64(pBit))/2, 127)<<1 | pBit)
+	}
+
+	return endpoint
+}
+
+// bc7Indices returns the nearest of the 16 interpolated colours to every pixel,
+// and the total squared error
+func bc7Indices(pixels *[16][4]uint8, e0 [4]uint8, e1 [4]uint8) ([16]int, int) {
+	var palette [16][4]int
+	for i := range palette {
+		for c := 0; c < 4; c++ {
+			palette[i][c] = bcInterpolate(int(e0[c]), int(e1[c]), bcWeights[4][i])
+		}
+	}
+
+	var indices [16]int
+	total := 0
+	for i := range pixels {
+		bestError := math.MaxInt32
+		for index := range palette {
+			err := 0
+			for c := 0; c < 4; c++ {
+				d := palette[index][c] - int(pixels[i][c])
+				err += d * d
+			}
+			if err < bestError {
+				indices[i], bestError = index, err
+			}
+		}
+		total += bestError
+	}
+
+	return indices, total
+}
+
+// write appends the low count bits of a value
+func (bits *blockBits) write(value int, count int) {
+	for i := 0; i < count; i++ {
+		bit := uint64(value>>uint(i)) & 1
+		if bits.pos < 64 {
+			bits.lo |= bit << bits.pos
+		} else {
+			bits.hi |= bit << (bits.pos - 64)
+		}
+		bits.pos++
+	}
+}
+
+// dot4 is the dot product of 2 vectors
+func dot4(a [4]float64, b [4]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] + a[3]*b[3]
+}
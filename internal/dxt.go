@@ -0,0 +1,196 @@
+package internal
+
+import (
+	"encoding/binary"
+	"image"
+	"math"
+)
+
+// Dxt block dimensions. All S3TC formats compress 4x4 pixel blocks
+const dxtBlockDimension = 4
+
+// DxtBlockCount returns the number of blocks required to cover a surface.
+// Surfaces smaller than a block are padded out to a full block
+func DxtBlockCount(width int, height int) (int, int) {
+	return (width + dxtBlockDimension - 1) / dxtBlockDimension, (height + dxtBlockDimension - 1) / dxtBlockDimension
+}
+
+// DecompressDxt1 decompresses Dxt1 data into an NRGBA image.
+// Dxt1 with and without one bit alpha share a block layout, so this
+// handles both. Data must contain at least 8 bytes per block.
+func DecompressDxt1(data []byte, width int, height int) *image.NRGBA {
+	return decompressDxt(data, width, height, 8, func(block []byte, pixels *[16][4]uint8) {
+		decodeDxtColourBlock(block, pixels, true)
+	})
+}
+
+// DecompressDxt3 decompresses Dxt3 data into an NRGBA image.
+// Data must contain at least 16 bytes per block.
+func DecompressDxt3(data []byte, width int, height int) *image.NRGBA {
+	return decompressDxt(data, width, height, 16, func(block []byte, pixels *[16][4]uint8) {
+		decodeDxtColourBlock(block[8:], pixels, false)
+		alpha := binary.LittleEndian.Uint64(block[:8])
+		for i := 0; i < 16; i++ {
+			a := uint8(alpha>>(4*uint(i))) & 0x0f
+			pixels[i][3] = a<<4 | a
+		}
+	})
+}
+
+// DecompressDxt5 decompresses Dxt5 data into an NRGBA image.
+// Data must contain at least 16 bytes per block.
+func DecompressDxt5(data []byte, width int, height int) *image.NRGBA {
+	return decompressDxt(data, width, height, 16, func(block []byte, pixels *[16][4]uint8) {
+		decodeDxtColourBlock(block[8:], pixels, false)
+		var alphas [16]uint8
+		decodeInterpolatedBlock(block, &alphas)
+		for i := 0; i < 16; i++ {
+			pixels[i][3] = alphas[i]
+		}
+	})
+}
+
+// DecompressATI1N decompresses single channel ATI1N (BC4) data into a Gray image.
+// Data must contain at least 8 bytes per block.
+func DecompressATI1N(data []byte, width int, height int) *image.Gray {
+	decoded := decompressDxt(data, width, height, 8, func(block []byte, pixels *[16][4]uint8) {
+		var values [16]uint8
+		decodeInterpolatedBlock(block, &values)
+		for i := 0; i < 16; i++ {
+			pixels[i] = [4]uint8{values[i], values[i], values[i], 255}
+		}
+	})
+
+	img := image.NewGray(decoded.Rect)
+	for i := range img.Pix {
+		img.Pix[i] = decoded.Pix[i*4]
+	}
+
+	return img
+}
+
+// DecompressATI2N decompresses two channel ATI2N (BC5) normal maps into an NRGBA image.
+// The first channel is X, stored as red, and the second is Y, stored as green.
+// Z is reconstructed into blue, as normals are unit length.
+// Data must contain at least 16 bytes per block.
+func DecompressATI2N(data []byte, width int, height int) *image.NRGBA {
+	return decompressDxt(data, width, height, 16, func(block []byte, pixels *[16][4]uint8) {
+		var xs, ys [16]uint8
+		decodeInterpolatedBlock(block[:8], &xs)
+		decodeInterpolatedBlock(block[8:], &ys)
+		for i := 0; i < 16; i++ {
+			pixels[i] = [4]uint8{xs[i], ys[i], reconstructNormalZ(xs[i], ys[i]), 255}
+		}
+	})
+}
+
+// reconstructNormalZ computes the Z component of a unit length normal from X & Y,
+// all mapped from [-1,1] to [0,255]
+func reconstructNormalZ(x uint8, y uint8) uint8 {
+	nx := float64(x)/127.5 - 1
+	ny := float64(y)/127.5 - 1
+	nz := 1 - nx*nx - ny*ny
+	if nz <= 0 {
+		return 128
+	}
+
+	return uint8((math.Sqrt(nz)+1)*127.5 + 0.5)
+}
+
+// decodeInterpolatedBlock decodes an 8 byte block of 8 bit values interpolated between
+// two endpoints; the alpha of Dxt5 and each channel of ATI1N & ATI2N
+func decodeInterpolatedBlock(block []byte, values *[16]uint8) {
+	var palette [8]uint8
+	DxtInterpolatedAlphaPalette(block[0], block[1], &palette)
+	indices := uint64(block[2]) | uint64(block[3])<<8 | uint64(block[4])<<16 |
+		uint64(block[5])<<24 | uint64(block[6])<<32 | uint64(block[7])<<40
+	for i := 0; i < 16; i++ {
+		values[i] = palette[(indices>>(3*uint(i)))&0x07]
+	}
+}
+
+// DxtInterpolatedAlphaPalette builds the 8 entry alpha palette used by Dxt5 blocks.
+func DxtInterpolatedAlphaPalette(a0 uint8, a1 uint8, palette *[8]uint8) {
+	palette[0] = a0
+	palette[1] = a1
+	if a0 > a1 {
+		for i := 1; i < 7; i++ {
+			palette[i+1] = uint8(((7-i)*int(a0) + i*int(a1)) / 7)
+		}
+		return
+	}
+	for i := 1; i < 5; i++ {
+		palette[i+1] = uint8(((5-i)*int(a0) + i*int(a1)) / 5)
+	}
+	palette[6] = 0
+	palette[7] = 255
+}
+
+// DxtColourPalette builds the 4 entry colour palette of a Dxt colour block.
+// When allowAlpha is set (Dxt1 only), c0 <= c1 selects 3 colours plus
+// transparent black.
+func DxtColourPalette(c0 uint16, c1 uint16, allowAlpha bool, palette *[4][4]uint8) {
+	palette[0] = Expand565(c0)
+	palette[1] = Expand565(c1)
+	if c0 > c1 || !allowAlpha {
+		for i := 0; i < 3; i++ {
+			palette[2][i] = uint8((2*int(palette[0][i]) + int(palette[1][i])) / 3)
+			palette[3][i] = uint8((int(palette[0][i]) + 2*int(palette[1][i])) / 3)
+		}
+		palette[2][3] = 255
+		palette[3][3] = 255
+		return
+	}
+	for i := 0; i < 3; i++ {
+		palette[2][i] = uint8((int(palette[0][i]) + int(palette[1][i])) / 2)
+	}
+	palette[2][3] = 255
+	palette[3] = [4]uint8{0, 0, 0, 0}
+}
+
+// Expand565 expands a packed 5:6:5 colour to 8 bit per channel RGBA
+func Expand565(c uint16) [4]uint8 {
+	return [4]uint8{expand5(c >> 11), expand6(c >> 5), expand5(c), 255}
+}
+
+// decodeDxtColourBlock decodes the 8 byte colour portion of a Dxt block
+func decodeDxtColourBlock(block []byte, pixels *[16][4]uint8, allowAlpha bool) {
+	var palette [4][4]uint8
+	DxtColourPalette(binary.LittleEndian.Uint16(block[0:2]), binary.LittleEndian.Uint16(block[2:4]), allowAlpha, &palette)
+	indices := binary.LittleEndian.Uint32(block[4:8])
+	for i := 0; i < 16; i++ {
+		pixels[i] = palette[(indices>>(2*uint(i)))&0x03]
+	}
+}
+
+// decompressDxt walks every block of a surface and writes the decoded
+// pixels into a new image. Pixels that fall within block padding are discarded.
+func decompressDxt(data []byte, width int, height int, blockSize int, decodeBlock func([]byte, *[16][4]uint8)) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	blocksWide, blocksHigh := DxtBlockCount(width, height)
+
+	var pixels [16][4]uint8
+	offset := 0
+	for by := 0; by < blocksHigh; by++ {
+		for bx := 0; bx < blocksWide; bx++ {
+			decodeBlock(data[offset:offset+blockSize], &pixels)
+			offset += blockSize
+
+			for py := 0; py < dxtBlockDimension; py++ {
+				y := by*dxtBlockDimension + py
+				if y >= height {
+					break
+				}
+				for px := 0; px < dxtBlockDimension; px++ {
+					x := bx*dxtBlockDimension + px
+					if x >= width {
+						break
+					}
+					copy(img.Pix[img.PixOffset(x, y):], pixels[py*dxtBlockDimension+px][:])
+				}
+			}
+		}
+	}
+
+	return img
+}
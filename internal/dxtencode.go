@@ -0,0 +1,440 @@
+package internal
+
+import (
+	"encoding/binary"
+	"image"
+	"math"
+	"sort"
+)
+
+// DxtQuality selects how Dxt block endpoints are searched for
+type DxtQuality int
+
+const (
+	// DxtQualityRangeFit uses the extremes of each block along its principal axis
+	DxtQualityRangeFit DxtQuality = iota
+	// DxtQualityClusterFit searches every ordered clustering of each block
+	// for the endpoints with the least squared error
+	DxtQualityClusterFit
+)
+
+// dxtOneBitAlphaThreshold is the alpha value below which a pixel is
+// considered transparent by Dxt1 with one bit alpha
+const dxtOneBitAlphaThreshold = 128
+
+// CompressDxt1 compresses an image into Dxt1 blocks.
+// When oneBitAlpha is set, pixels with alpha below 128 are stored as transparent;
+// otherwise alpha is ignored.
+func CompressDxt1(img *image.NRGBA, oneBitAlpha bool, quality DxtQuality) []byte {
+	return compressDxt(img, 8, func(pixels *[16][4]uint8, dst []byte) {
+		var transparent uint16
+		if oneBitAlpha {
+			for i := range pixels {
+				if pixels[i][3] < dxtOneBitAlphaThreshold {
+					transparent |= 1 << uint(i)
+				}
+			}
+		}
+		compressDxtColourBlock(pixels, transparent, true, quality, dst)
+	})
+}
+
+// CompressDxt3 compresses an image into Dxt3 blocks, with explicit 4 bit alpha
+func CompressDxt3(img *image.NRGBA, quality DxtQuality) []byte {
+	return compressDxt(img, 16, func(pixels *[16][4]uint8, dst []byte) {
+		var alpha uint64
+		for i := range pixels {
+			alpha |= uint64((int(pixels[i][3])*15+127)/255) << (4 * uint(i))
+		}
+		binary.LittleEndian.PutUint64(dst[:8], alpha)
+		compressDxtColourBlock(pixels, 0, false, quality, dst[8:])
+	})
+}
+
+// CompressDxt5 compresses an image into Dxt5 blocks, with interpolated alpha
+func CompressDxt5(img *image.NRGBA, quality DxtQuality) []byte {
+	return compressDxt(img, 16, func(pixels *[16][4]uint8, dst []byte) {
+		compressDxt5AlphaBlock(pixels, quality, dst[:8])
+		compressDxtColourBlock(pixels, 0, false, quality, dst[8:])
+	})
+}
+
+// compressDxt splits an image into 4x4 blocks and compresses each.
+// Blocks that overhang the image edge repeat the edge pixels.
+func compressDxt(img *image.NRGBA, blockSize int, compressBlock func(*[16][4]uint8, []byte)) []byte {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	blocksWide, blocksHigh := DxtBlockCount(width, height)
+	out := make([]byte, blocksWide*blocksHigh*blockSize)
+
+	var pixels [16][4]uint8
+	offset := 0
+	for by := 0; by < blocksHigh; by++ {
+		for bx := 0; bx < blocksWide; bx++ {
+			for py := 0; py < dxtBlockDimension; py++ {
+				y := by*dxtBlockDimension + py
+				if y >= height {
+					y = height - 1
+				}
+				for px := 0; px < dxtBlockDimension; px++ {
+					x := bx*dxtBlockDimension + px
+					if x >= width {
+						x = width - 1
+					}
+					copy(pixels[py*dxtBlockDimension+px][:], img.Pix[img.PixOffset(img.Rect.Min.X+x, img.Rect.Min.Y+y):])
+				}
+			}
+			compressBlock(&pixels, out[offset:offset+blockSize])
+			offset += blockSize
+		}
+	}
+
+	return out
+}
+
+// compressDxtColourBlock writes the 8 byte colour portion of a Dxt block.
+// Pixels set in the transparent mask are written as transparent black, which
+// forces the 3 colour mode that only Dxt1 supports.
+func compressDxtColourBlock(pixels *[16][4]uint8, transparent uint16, isDxt1 bool, quality DxtQuality, dst []byte) {
+	points := make([][3]float64, 0, 16)
+	for i := range pixels {
+		if transparent&(1<<uint(i)) == 0 {
+			points = append(points, [3]float64{float64(pixels[i][0]), float64(pixels[i][1]), float64(pixels[i][2])})
+		}
+	}
+	if len(points) == 0 {
+		// Fully transparent
+		binary.LittleEndian.PutUint16(dst[0:], 0)
+		binary.LittleEndian.PutUint16(dst[2:], 0)
+		binary.LittleEndian.PutUint32(dst[4:], 0xffffffff)
+		return
+	}
+
+	threeColour := transparent != 0
+	axis := principalAxis(points)
+
+	c0, c1 := rangeFitEndpoints(points, axis, threeColour)
+	bestIndices, bestError := dxtColourIndices(pixels, transparent, c0, c1, isDxt1)
+	if quality == DxtQualityClusterFit {
+		cc0, cc1 := clusterFitEndpoints(points, axis, threeColour)
+		if indices, err := dxtColourIndices(pixels, transparent, cc0, cc1, isDxt1); err < bestError {
+			c0, c1, bestIndices, bestError = cc0, cc1, indices, err
+		}
+	}
+
+	binary.LittleEndian.PutUint16(dst[0:], c0)
+	binary.LittleEndian.PutUint16(dst[2:], c1)
+	binary.LittleEndian.PutUint32(dst[4:], bestIndices)
+}
+
+// dxtColourIndices selects the nearest palette entry for every pixel, and
+// returns the packed indices and total squared error.
+func dxtColourIndices(pixels *[16][4]uint8, transparent uint16, c0 uint16, c1 uint16, isDxt1 bool) (uint32, float64) {
+	var palette [4][4]uint8
+	DxtColourPalette(c0, c1, isDxt1, &palette)
+
+	var indices uint32
+	total := 0.0
+	for i := range pixels {
+		if transparent&(1<<uint(i)) != 0 {
+			indices |= 3 << (2 * uint(i))
+			continue
+		}
+		best, bestError := 0, math.MaxFloat64
+		for p := range palette {
+			if palette[p][3] == 0 {
+				continue
+			}
+			err := 0.0
+			for c := 0; c < 3; c++ {
+				d := float64(pixels[i][c]) - float64(palette[p][c])
+				err += d * d
+			}
+			if err < bestError {
+				best, bestError = p, err
+			}
+		}
+		indices |= uint32(best) << (2 * uint(i))
+		total += bestError
+	}
+
+	return indices, total
+}
+
+// orderEndpoints quantises a pair of endpoints and orders them to select
+// the 3 or 4 colour block mode
+func orderEndpoints(start [3]float64, end [3]float64, threeColour bool) (uint16, uint16) {
+	a, b := quantise565(start), quantise565(end)
+	if (a < b) != threeColour && a != b {
+		a, b = b, a
+	}
+
+	return a, b
+}
+
+// rangeFitEndpoints uses the points at either extreme of the principal axis as endpoints
+func rangeFitEndpoints(points [][3]float64, axis [3]float64, threeColour bool) (uint16, uint16) {
+	minIdx, maxIdx := 0, 0
+	minProj, maxProj := math.MaxFloat64, -math.MaxFloat64
+	for i := range points {
+		proj := dot3(points[i], axis)
+		if proj < minProj {
+			minProj, minIdx = proj, i
+		}
+		if proj > maxProj {
+			maxProj, maxIdx = proj, i
+		}
+	}
+
+	return orderEndpoints(points[minIdx], points[maxIdx], threeColour)
+}
+
+// clusterFitEndpoints orders points along the principal axis, then tries every
+// way of splitting them into contiguous clusters, solving for the least squares
+// endpoints of each split.
+func clusterFitEndpoints(points [][3]float64, axis [3]float64, threeColour bool) (uint16, uint16) {
+	ordered := make([][3]float64, len(points))
+	copy(ordered, points)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return dot3(ordered[i], axis) < dot3(ordered[j], axis)
+	})
+
+	// Prefix sums allow each cluster sum in constant time
+	n := len(ordered)
+	prefix := make([][3]float64, n+1)
+	xx := 0.0
+	for i := range ordered {
+		for c := 0; c < 3; c++ {
+			prefix[i+1][c] = prefix[i][c] + ordered[i][c]
+		}
+		xx += dot3(ordered[i], ordered[i])
+	}
+	clusterSum := func(from int, to int) [3]float64 {
+		return [3]float64{prefix[to][0] - prefix[from][0], prefix[to][1] - prefix[from][1], prefix[to][2] - prefix[from][2]}
+	}
+
+	// Interpolation weight of the start endpoint for each cluster
+	weights := []float64{1, 2.0 / 3, 1.0 / 3, 0}
+	if threeColour {
+		weights = []float64{1, 0.5, 0}
+	}
+
+	bestError := math.MaxFloat64
+	var bestStart, bestEnd [3]float64
+	bounds := make([]int, len(weights)+1)
+	bounds[len(weights)] = n
+
+	var search func(cluster int)
+	search = func(cluster int) {
+		if cluster < len(weights) {
+			for bounds[cluster] = bounds[cluster-1]; bounds[cluster] <= n; bounds[cluster]++ {
+				search(cluster + 1)
+			}
+			return
+		}
+
+		var alpha2, beta2, alphaBeta float64
+		var alphaX, betaX [3]float64
+		for i, alpha := range weights {
+			count := float64(bounds[i+1] - bounds[i])
+			if count == 0 {
+				continue
+			}
+			beta := 1 - alpha
+			alpha2 += alpha * alpha * count
+			beta2 += beta * beta * count
+			alphaBeta += alpha * beta * count
+			sum := clusterSum(bounds[i], bounds[i+1])
+			for c := 0; c < 3; c++ {
+				alphaX[c] += alpha * sum[c]
+				betaX[c] += beta * sum[c]
+			}
+		}
+
+		determinant := alpha2*beta2 - alphaBeta*alphaBeta
+		if math.Abs(determinant) < 1e-9 {
+			return
+		}
+
+		var start, end [3]float64
+		for c := 0; c < 3; c++ {
+			start[c] = snapToGrid((alphaX[c]*beta2-betaX[c]*alphaBeta)/determinant, c)
+			end[c] = snapToGrid((betaX[c]*alpha2-alphaX[c]*alphaBeta)/determinant, c)
+		}
+
+		// Squared error of this clustering with the snapped endpoints
+		err := xx
+		for c := 0; c < 3; c++ {
+			err += start[c]*start[c]*alpha2 + end[c]*end[c]*beta2 +
+				2*(start[c]*end[c]*alphaBeta-start[c]*alphaX[c]-end[c]*betaX[c])
+		}
+		if err < bestError {
+			bestError, bestStart, bestEnd = err, start, end
+		}
+	}
+	search(1)
+
+	if bestError == math.MaxFloat64 {
+		return rangeFitEndpoints(points, axis, threeColour)
+	}
+
+	return orderEndpoints(bestStart, bestEnd, threeColour)
+}
+
+// compressDxt5AlphaBlock writes the 8 byte interpolated alpha portion of a Dxt5 block.
+// The 8 alpha mode is always tried; cluster fit quality also tries the 6 alpha
+// mode with explicit 0 and 255.
+func compressDxt5AlphaBlock(pixels *[16][4]uint8, quality DxtQuality, dst []byte) {
+	minAlpha, maxAlpha := uint8(255), uint8(0)
+	minInner, maxInner := uint8(255), uint8(0)
+	for i := range pixels {
+		a := pixels[i][3]
+		if a < minAlpha {
+			minAlpha = a
+		}
+		if a > maxAlpha {
+			maxAlpha = a
+		}
+		if a != 0 && a != 255 {
+			if a < minInner {
+				minInner = a
+			}
+			if a > maxInner {
+				maxInner = a
+			}
+		}
+	}
+
+	a0, a1 := maxAlpha, minAlpha
+	indices, bestError := dxtAlphaIndices(pixels, a0, a1)
+	if quality == DxtQualityClusterFit {
+		if minInner > maxInner {
+			minInner, maxInner = 0, 0
+		}
+		if candidate, err := dxtAlphaIndices(pixels, minInner, maxInner); err < bestError {
+			a0, a1, indices = minInner, maxInner, candidate
+		}
+	}
+
+	dst[0] = a0
+	dst[1] = a1
+	for i := 0; i < 6; i++ {
+		dst[2+i] = uint8(indices >> (8 * uint(i)))
+	}
+}
+
+// dxtAlphaIndices selects the nearest alpha palette entry for every pixel, and
+// returns the packed indices and total squared error.
+func dxtAlphaIndices(pixels *[16][4]uint8, a0 uint8, a1 uint8) (uint64, int) {
+	var palette [8]uint8
+	DxtInterpolatedAlphaPalette(a0, a1, &palette)
+
+	var indices uint64
+	total := 0
+	for i := range pixels {
+		best, bestError := 0, math.MaxInt32
+		for p := range palette {
+			d := int(pixels[i][3]) - int(palette[p])
+			if d*d < bestError {
+				best, bestError = p, d*d
+			}
+		}
+		indices |= uint64(best) << (3 * uint(i))
+		total += bestError
+	}
+
+	return indices, total
+}
+
+// principalAxis finds the direction of greatest variance of a set of colours
+// by power iteration on their covariance matrix
+func principalAxis(points [][3]float64) [3]float64 {
+	var mean [3]float64
+	for _, p := range points {
+		for c := 0; c < 3; c++ {
+			mean[c] += p[c]
+		}
+	}
+	for c := 0; c < 3; c++ {
+		mean[c] /= float64(len(points))
+	}
+
+	var covariance [3][3]float64
+	for _, p := range points {
+		d := [3]float64{p[0] - mean[0], p[1] - mean[1], p[2] - mean[2]}
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				covariance[i][j] += d[i] * d[j]
+			}
+		}
+	}
+
+	// Start from the covariance row of the channel that varies most. A fixed start can be
+	// orthogonal to the axis, as when channels vary in opposite directions
+	largest := 0
+	for i := 1; i < 3; i++ {
+		if covariance[i][i] > covariance[largest][largest] {
+			largest = i
+		}
+	}
+	axis := [3]float64{1, 1, 1}
+	if covariance[largest][largest] > 0 {
+		axis = covariance[largest]
+	}
+	for iteration := 0; iteration < 8; iteration++ {
+		var next [3]float64
+		for i := 0; i < 3; i++ {
+			next[i] = dot3(covariance[i], axis)
+		}
+		length := math.Sqrt(dot3(next, next))
+		if length < 1e-9 {
+			break
+		}
+		for i := 0; i < 3; i++ {
+			axis[i] = next[i] / length
+		}
+	}
+
+	return axis
+}
+
+// quantise565 packs an 8 bit per channel colour into 5:6:5
+func quantise565(c [3]float64) uint16 {
+	r := uint16(clampRound(c[0]*31/255, 31))
+	g := uint16(clampRound(c[1]*63/255, 63))
+	b := uint16(clampRound(c[2]*31/255, 31))
+
+	return r<<11 | g<<5 | b
+}
+
+// snapToGrid rounds a channel value to the nearest value representable in 5:6:5
+func snapToGrid(v float64, channel int) float64 {
+	levels := 31.0
+	if channel == 1 {
+		levels = 63
+	}
+	q := clampRound(v*levels/255, int(levels))
+	expanded := Expand565(uint16(q) << 11)[0]
+	if channel == 1 {
+		expanded = Expand565(uint16(q) << 5)[1]
+	}
+
+	return float64(expanded)
+}
+
+// clampRound rounds v to the nearest integer in [0, max]
+func clampRound(v float64, max int) int {
+	r := int(math.Floor(v + 0.5))
+	if r < 0 {
+		return 0
+	}
+	if r > max {
+		return max
+	}
+
+	return r
+}
+
+// dot3 is the dot product of 2 vectors
+func dot3(a [3]float64, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
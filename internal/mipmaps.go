@@ -2,108 +2,56 @@ package internal
 
 import (
 	"github.com/galaco/vtf/format"
-	"math"
 )
 
-// ComputeMipmapSizes computes all mipmap sizes
-func ComputeMipmapSizes(num int, width int, height int) [][2]int {
-	mipmaps := make([][2]int, num)
+// ComputeMipmapSizes computes all mipmap sizes as width, height & depth.
+// Each mipmap halves the size of the next largest, rounding down, to a minimum of 1.
+// Smallest mipmap is first
+func ComputeMipmapSizes(num int, width int, height int, depth int) [][3]int {
+	mipmaps := make([][3]int, num)
 
 	for i := num - 1; i >= 0; i-- {
-		mipmaps[i] = [2]int{width, height}
+		mipmaps[i] = [3]int{width, height, depth}
 
-		width = int(math.Ceil(float64(width) / 2))
-		height = int(math.Ceil(float64(height) / 2))
+		width = halve(width)
+		height = halve(height)
+		depth = halve(depth)
 	}
 
 	return mipmaps
 }
 
-// ComputeSizeOfMipmapData returns the size in bytes
-func ComputeSizeOfMipmapData(width int, height int, storedFormat format.Format) int {
-	// Supported compressed formats must be at least 4x4.
-	// The format pads smaller sizes out to 4
-	if isCompressedFormat(storedFormat) {
-		if width < 4 {
-			width = 4
-		}
-		if height < 4 {
-			height = 4
-		}
+// halve halves a mipmap dimension, rounding down to a minimum of 1 as Source does.
+// Reading locates mipmaps by these sizes, so rounding affects non power of 2 textures
+func halve(size int) int {
+	if size <= 1 {
+		return 1
 	}
 
-	return int(bytesPerPixel(storedFormat) * float32(width) * float32(height))
+	return size / 2
 }
 
-// isCompressedFormat determines whether the provided format
-// is a compressed format. Supported compressed formats are Dxt* only
-func isCompressedFormat(storedFormat format.Format) bool {
-	if storedFormat == format.Dxt1 ||
-		storedFormat == format.Dxt3 ||
-		storedFormat == format.Dxt5 {
-		return true
+// MipmapCount returns the number of mipmaps in a full chain for a texture;
+// one for every bit of its largest dimension
+func MipmapCount(width int, height int, depth int) int {
+	largest := width
+	if height > largest {
+		largest = height
+	}
+	if depth > largest {
+		largest = depth
 	}
 
-	return false
-}
-
-// bytesPerPixel gets number of bytes required to represent a single pixel
-// May be non-integer for compressed formats
-func bytesPerPixel(storedFormat format.Format) float32 {
-	switch storedFormat {
-	case format.RGBA8888:
-		return 4
-	case format.ABGR8888:
-		return 4
-	case format.RGB888:
-		return 3
-	case format.BGR888:
-		return 3
-	case format.RGB565:
-		return 2
-	case format.I8:
-		return 1
-	case format.IA88:
-		return 2
-	case format.P8:
-		return 1
-	case format.A8:
-		return 1
-	case format.RGB888BLUESCREEN:
-		return 3
-	case format.BGR888BLUESCREEN:
-		return 3
-	case format.ARGB8888:
-		return 4
-	case format.BGRA8888:
-		return 4
-	case format.Dxt1:
-		return 0.5
-	case format.Dxt5:
-		return 1
-	case format.BGRX8888:
-		return 4
-	case format.BGR565:
-		return 2
-	case format.BGRX5551:
-		return 2
-	case format.BGRA4444:
-		return 2
-	case format.Dxt1OneBitAlpha:
-		return 0.5
-	case format.BGRA5551:
-		return 2
-	case format.UV88:
-		return 2
-	case format.UVWQ8888:
-		return 4
-	case format.RGBA16161616F:
-		return 8
-	case format.RGBA16161616:
-		return 8
-	case format.UVLX8888:
-		return 4
+	count := 0
+	for ; largest > 0; largest >>= 1 {
+		count++
 	}
 
-	return 0
+	return count
+}
+
+// ComputeSizeOfMipmapData returns the size in bytes
+// Returns 0 for formats of unknown size
+func ComputeSizeOfMipmapData(width int, height int, storedFormat format.Format) int {
+	return storedFormat.SurfaceSize(width, height, 1)
 }
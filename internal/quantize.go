@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"image"
+	"image/color"
+	"sort"
+)
+
+// colourBox is a set of colours for median cut quantisation
+type colourBox struct {
+	colours [][3]uint8
+}
+
+// channelRange returns the widest channel of a box and its range
+func (box *colourBox) channelRange() (int, int) {
+	widest, widestRange := 0, -1
+	for c := 0; c < 3; c++ {
+		lo, hi := uint8(255), uint8(0)
+		for _, colour := range box.colours {
+			if colour[c] < lo {
+				lo = colour[c]
+			}
+			if colour[c] > hi {
+				hi = colour[c]
+			}
+		}
+		if int(hi)-int(lo) > widestRange {
+			widest, widestRange = c, int(hi)-int(lo)
+		}
+	}
+
+	return widest, widestRange
+}
+
+// average returns the mean colour of a box
+func (box *colourBox) average() color.NRGBA {
+	var sum [3]int
+	for _, colour := range box.colours {
+		for c := 0; c < 3; c++ {
+			sum[c] += int(colour[c])
+		}
+	}
+	n := len(box.colours)
+
+	return color.NRGBA{
+		R: uint8((sum[0] + n/2) / n),
+		G: uint8((sum[1] + n/2) / n),
+		B: uint8((sum[2] + n/2) / n),
+		A: 255,
+	}
+}
+
+// MedianCutPalette builds a palette of at most size opaque colours that represents
+// the pixels of an image with alpha of at least alphaThreshold.
+// Boxes of colours are repeatedly split at the median of their widest channel.
+// The result is deterministic.
+func MedianCutPalette(img *image.NRGBA, size int, alphaThreshold uint8) color.Palette {
+	bounds := img.Bounds()
+	colours := make([][3]uint8, 0, bounds.Dx()*bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := img.NRGBAAt(x, y)
+			if c.A >= alphaThreshold {
+				colours = append(colours, [3]uint8{c.R, c.G, c.B})
+			}
+		}
+	}
+	if len(colours) == 0 || size <= 0 {
+		return color.Palette{}
+	}
+
+	boxes := []*colourBox{{colours: colours}}
+	for len(boxes) < size {
+		// Split the box with the widest channel range
+		splitIdx, splitChannel, splitRange := -1, 0, 0
+		for idx, box := range boxes {
+			if len(box.colours) < 2 {
+				continue
+			}
+			channel, r := box.channelRange()
+			if r > splitRange {
+				splitIdx, splitChannel, splitRange = idx, channel, r
+			}
+		}
+		if splitIdx < 0 {
+			break
+		}
+
+		box := boxes[splitIdx]
+		sort.SliceStable(box.colours, func(i, j int) bool {
+			return box.colours[i][splitChannel] < box.colours[j][splitChannel]
+		})
+		median := len(box.colours) / 2
+		boxes[splitIdx] = &colourBox{colours: box.colours[:median]}
+		boxes = append(boxes, &colourBox{colours: box.colours[median:]})
+	}
+
+	palette := make(color.Palette, len(boxes))
+	for idx, box := range boxes {
+		palette[idx] = box.average()
+	}
+
+	return palette
+}
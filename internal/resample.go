@@ -0,0 +1,234 @@
+package internal
+
+import (
+	"image"
+	"math"
+)
+
+// ResampleFilter is a reconstruction filter used when resizing images
+type ResampleFilter struct {
+	// Support is the radius of the filter, in source pixels at a scale of 1
+	Support float64
+	// Kernel returns the weight of a sample at a distance from the centre
+	Kernel func(x float64) float64
+}
+
+var (
+	// FilterBox averages every source pixel that a destination pixel covers
+	FilterBox = ResampleFilter{Support: 0.5, Kernel: func(x float64) float64 {
+		if x >= -0.5 && x <= 0.5 {
+			return 1
+		}
+		return 0
+	}}
+	// FilterTriangle is a linear (tent) filter
+	FilterTriangle = ResampleFilter{Support: 1, Kernel: func(x float64) float64 {
+		x = math.Abs(x)
+		if x < 1 {
+			return 1 - x
+		}
+		return 0
+	}}
+	// FilterKaiser is a Kaiser windowed sinc with a width of 3 & alpha of 4
+	FilterKaiser = ResampleFilter{Support: 3, Kernel: func(x float64) float64 {
+		const width, alpha = 3.0, 4.0
+		t := x / width
+		if t*t >= 1 {
+			return 0
+		}
+		return sinc(x) * bessel0(alpha*math.Sqrt(1-t*t)) / bessel0(alpha)
+	}}
+	// FilterLanczos is a 3 lobe Lanczos windowed sinc
+	FilterLanczos = ResampleFilter{Support: 3, Kernel: func(x float64) float64 {
+		if x <= -3 || x >= 3 {
+			return 0
+		}
+		return sinc(x) * sinc(x/3)
+	}}
+)
+
+// srgbToLinear is a lookup table from 8 bit sRGB to linear light
+var srgbToLinear = func() [256]float32 {
+	var table [256]float32
+	for idx := range table {
+		c := float64(idx) / 255
+		if c <= 0.04045 {
+			table[idx] = float32(c / 12.92)
+		} else {
+			table[idx] = float32(math.Pow((c+0.055)/1.055, 2.4))
+		}
+	}
+	return table
+}()
+
+// linearToSRGB converts linear light in [0,1] to 8 bit sRGB
+func linearToSRGB(c float32) uint8 {
+	if !(c > 0) {
+		return 0
+	}
+	if c >= 1 {
+		return 255
+	}
+	if c <= 0.0031308 {
+		return uint8(float64(c)*12.92*255 + 0.5)
+	}
+
+	return uint8((1.055*math.Pow(float64(c), 1/2.4)-0.055)*255 + 0.5)
+}
+
+// Resize resamples an image to a new size with a separable filter.
+// Colour is weighted by alpha, so fully transparent pixels do not bleed into
+// their neighbours. When linear is set, colour is treated as sRGB and filtered
+// in linear light. Edges are clamped.
+func Resize(img *image.NRGBA, width int, height int, filter ResampleFilter, linear bool) *image.NRGBA {
+	srcWidth, srcHeight := img.Rect.Dx(), img.Rect.Dy()
+
+	// Premultiplied floating point source
+	src := make([]float32, srcWidth*srcHeight*4)
+	for y := 0; y < srcHeight; y++ {
+		for x := 0; x < srcWidth; x++ {
+			p := img.Pix[img.PixOffset(img.Rect.Min.X+x, img.Rect.Min.Y+y):]
+			out := src[(y*srcWidth+x)*4:]
+			alpha := float32(p[3]) / 255
+			for c := 0; c < 3; c++ {
+				if linear {
+					out[c] = srgbToLinear[p[c]] * alpha
+				} else {
+					out[c] = float32(p[c]) / 255 * alpha
+				}
+			}
+			out[3] = alpha
+		}
+	}
+
+	// Horizontal, then vertical
+	horizontal := make([]float32, width*srcHeight*4)
+	weights := filterWeights(srcWidth, width, filter)
+	for y := 0; y < srcHeight; y++ {
+		for x := 0; x < width; x++ {
+			out := horizontal[(y*width+x)*4:]
+			for _, w := range weights[x] {
+				in := src[(y*srcWidth+w.index)*4:]
+				for c := 0; c < 4; c++ {
+					out[c] += in[c] * w.weight
+				}
+			}
+		}
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	weights = filterWeights(srcHeight, height, filter)
+	var sum [4]float32
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sum = [4]float32{}
+			for _, w := range weights[y] {
+				in := horizontal[(w.index*width+x)*4:]
+				for c := 0; c < 4; c++ {
+					sum[c] += in[c] * w.weight
+				}
+			}
+
+			out := dst.Pix[dst.PixOffset(x, y):]
+			alpha := sum[3]
+			if alpha <= 0 {
+				out[0], out[1], out[2], out[3] = 0, 0, 0, 0
+				continue
+			}
+			for c := 0; c < 3; c++ {
+				if linear {
+					out[c] = linearToSRGB(sum[c] / alpha)
+				} else {
+					out[c] = unitFloatToUint8(sum[c] / alpha)
+				}
+			}
+			out[3] = unitFloatToUint8(alpha)
+		}
+	}
+
+	return dst
+}
+
+// filterTap is the contribution of a single source pixel
+type filterTap struct {
+	index  int
+	weight float32
+}
+
+// filterWeights computes the normalised source contributions of every destination
+// pixel along one axis
+func filterWeights(srcSize int, dstSize int, filter ResampleFilter) [][]filterTap {
+	scale := float64(srcSize) / float64(dstSize)
+	// Widen the filter when minifying, so every source pixel contributes
+	filterScale := scale
+	if filterScale < 1 {
+		filterScale = 1
+	}
+	support := filter.Support * filterScale
+
+	weights := make([][]filterTap, dstSize)
+	for idx := range weights {
+		centre := (float64(idx)+0.5)*scale - 0.5
+		start := int(math.Ceil(centre - support))
+		end := int(math.Floor(centre + support))
+
+		var taps []filterTap
+		total := 0.0
+		for s := start; s <= end; s++ {
+			w := filter.Kernel((float64(s) - centre) / filterScale)
+			if w == 0 {
+				continue
+			}
+			clamped := s
+			if clamped < 0 {
+				clamped = 0
+			}
+			if clamped >= srcSize {
+				clamped = srcSize - 1
+			}
+			taps = append(taps, filterTap{index: clamped, weight: float32(w)})
+			total += w
+		}
+		if total == 0 {
+			// Filter is narrower than the sample spacing; take the nearest pixel
+			nearest := int(math.Floor(centre + 0.5))
+			if nearest < 0 {
+				nearest = 0
+			}
+			if nearest >= srcSize {
+				nearest = srcSize - 1
+			}
+			taps, total = []filterTap{{index: nearest, weight: 1}}, 1
+		}
+		for t := range taps {
+			taps[t].weight = float32(float64(taps[t].weight) / total)
+		}
+		weights[idx] = taps
+	}
+
+	return weights
+}
+
+// sinc is the normalised sinc function
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	x *= math.Pi
+
+	return math.Sin(x) / x
+}
+
+// bessel0 is the zeroth order modified Bessel function of the first kind
+func bessel0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; k < 32; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+		if term < sum*1e-12 {
+			break
+		}
+	}
+
+	return sum
+}
@@ -0,0 +1,310 @@
+package internal
+
+import (
+	"encoding/binary"
+	"image"
+	"math"
+
+	"github.com/galaco/vtf/format"
+)
+
+// pixelDecoder converts a single stored pixel into 8 bit per channel non-premultiplied RGBA
+type pixelDecoder func(src []byte) [4]uint8
+
+// uncompressedFormat describes how a single uncompressed pixel is stored
+type uncompressedFormat struct {
+	size   int
+	decode pixelDecoder
+}
+
+// nrgbaFormats are all uncompressed formats that decode into an image.NRGBA.
+// Packed formats are little endian, with the first named channel in the lowest bits.
+var nrgbaFormats = map[format.Format]uncompressedFormat{
+	format.RGBA8888: {4, func(src []byte) [4]uint8 { return [4]uint8{src[0], src[1], src[2], src[3]} }},
+	format.ABGR8888: {4, func(src []byte) [4]uint8 { return [4]uint8{src[3], src[2], src[1], src[0]} }},
+	format.RGB888:   {3, func(src []byte) [4]uint8 { return [4]uint8{src[0], src[1], src[2], 255} }},
+	format.BGR888:   {3, func(src []byte) [4]uint8 { return [4]uint8{src[2], src[1], src[0], 255} }},
+	format.RGB565: {2, func(src []byte) [4]uint8 {
+		c := binary.LittleEndian.Uint16(src)
+		return [4]uint8{expand5(c), expand6(c >> 5), expand5(c >> 11), 255}
+	}},
+	format.IA88: {2, func(src []byte) [4]uint8 { return [4]uint8{src[0], src[0], src[0], src[1]} }},
+	format.RGB888BLUESCREEN: {3, func(src []byte) [4]uint8 {
+		return blueScreen(src[0], src[1], src[2])
+	}},
+	format.BGR888BLUESCREEN: {3, func(src []byte) [4]uint8 {
+		return blueScreen(src[2], src[1], src[0])
+	}},
+	format.ARGB8888: {4, func(src []byte) [4]uint8 { return [4]uint8{src[1], src[2], src[3], src[0]} }},
+	format.BGRA8888: {4, func(src []byte) [4]uint8 { return [4]uint8{src[2], src[1], src[0], src[3]} }},
+	format.BGRX8888: {4, func(src []byte) [4]uint8 { return [4]uint8{src[2], src[1], src[0], 255} }},
+	format.BGR565: {2, func(src []byte) [4]uint8 {
+		c := binary.LittleEndian.Uint16(src)
+		return [4]uint8{expand5(c >> 11), expand6(c >> 5), expand5(c), 255}
+	}},
+	format.BGRX5551: {2, func(src []byte) [4]uint8 {
+		c := binary.LittleEndian.Uint16(src)
+		return [4]uint8{expand5(c >> 10), expand5(c >> 5), expand5(c), 255}
+	}},
+	format.BGRA4444: {2, func(src []byte) [4]uint8 {
+		c := binary.LittleEndian.Uint16(src)
+		return [4]uint8{expand4(c >> 8), expand4(c >> 4), expand4(c), expand4(c >> 12)}
+	}},
+	format.BGRA5551: {2, func(src []byte) [4]uint8 {
+		c := binary.LittleEndian.Uint16(src)
+		return [4]uint8{expand5(c >> 10), expand5(c >> 5), expand5(c), uint8(c>>15) * 255}
+	}},
+	format.UV88:     {2, func(src []byte) [4]uint8 { return [4]uint8{src[0], src[1], 0, 255} }},
+	format.UVWQ8888: {4, func(src []byte) [4]uint8 { return [4]uint8{src[0], src[1], src[2], src[3]} }},
+	format.RGBA16161616F: {8, func(src []byte) [4]uint8 {
+		return [4]uint8{
+			unitFloatToUint8(HalfToFloat32(binary.LittleEndian.Uint16(src[0:]))),
+			unitFloatToUint8(HalfToFloat32(binary.LittleEndian.Uint16(src[2:]))),
+			unitFloatToUint8(HalfToFloat32(binary.LittleEndian.Uint16(src[4:]))),
+			unitFloatToUint8(HalfToFloat32(binary.LittleEndian.Uint16(src[6:]))),
+		}
+	}},
+	format.UVLX8888: {4, func(src []byte) [4]uint8 { return [4]uint8{src[0], src[1], src[2], src[3]} }},
+	format.R32F: {4, func(src []byte) [4]uint8 {
+		r := unitFloatToUint8(float32At(src, 0))
+		return [4]uint8{r, r, r, 255}
+	}},
+	format.RGB323232F: {12, func(src []byte) [4]uint8 {
+		return [4]uint8{
+			unitFloatToUint8(float32At(src, 0)),
+			unitFloatToUint8(float32At(src, 4)),
+			unitFloatToUint8(float32At(src, 8)),
+			255,
+		}
+	}},
+	format.RGBA32323232F: {16, func(src []byte) [4]uint8 {
+		return [4]uint8{
+			unitFloatToUint8(float32At(src, 0)),
+			unitFloatToUint8(float32At(src, 4)),
+			unitFloatToUint8(float32At(src, 8)),
+			unitFloatToUint8(float32At(src, 12)),
+		}
+	}},
+}
+
+// UncompressedPixelSize returns the number of bytes a single pixel of an
+// uncompressed format this package can decode occupies, or 0 if it cannot
+// be decoded.
+func UncompressedPixelSize(storedFormat format.Format) int {
+	switch storedFormat {
+	case format.I8, format.A8:
+		return 1
+	case format.RGBA16161616:
+		return 8
+	}
+	if f, ok := nrgbaFormats[storedFormat]; ok {
+		return f.size
+	}
+
+	return 0
+}
+
+// DecodeUncompressed decodes an uncompressed surface into the most appropriate
+// image type. I8 decodes to image.Gray, A8 to image.Alpha, RGBA16161616 to
+// image.NRGBA64, and everything else to image.NRGBA. High dynamic range formats
+// are clamped to [0,1].
+// Returns nil if the format is unsupported. P8 is unsupported, as vtf
+// does not store a palette.
+// Data must be at least width*height*UncompressedPixelSize bytes.
+func DecodeUncompressed(data []byte, width int, height int, storedFormat format.Format) image.Image {
+	rect := image.Rect(0, 0, width, height)
+
+	switch storedFormat {
+	case format.I8:
+		img := image.NewGray(rect)
+		for y := 0; y < height; y++ {
+			copy(img.Pix[y*img.Stride:y*img.Stride+width], data[y*width:])
+		}
+		return img
+	case format.A8:
+		img := image.NewAlpha(rect)
+		for y := 0; y < height; y++ {
+			copy(img.Pix[y*img.Stride:y*img.Stride+width], data[y*width:])
+		}
+		return img
+	case format.RGBA16161616:
+		img := image.NewNRGBA64(rect)
+		for i := 0; i < width*height*4; i++ {
+			// Stored little endian, image.NRGBA64 is big endian
+			img.Pix[i*2] = data[i*2+1]
+			img.Pix[i*2+1] = data[i*2]
+		}
+		return img
+	}
+
+	f, ok := nrgbaFormats[storedFormat]
+	if !ok {
+		return nil
+	}
+
+	img := image.NewNRGBA(rect)
+	for i := 0; i < width*height; i++ {
+		pixel := f.decode(data[i*f.size:])
+		copy(img.Pix[i*4:], pixel[:])
+	}
+
+	return img
+}
+
+// HalfToFloat32 converts an IEEE 754 half precision float to float32
+func HalfToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exponent := uint32(h>>10) & 0x1f
+	mantissa := uint32(h) & 0x3ff
+
+	switch {
+	case exponent == 0 && mantissa == 0:
+		return math.Float32frombits(sign)
+	case exponent == 0:
+		// Subnormal; normalise it
+		for mantissa&0x400 == 0 {
+			mantissa <<= 1
+			exponent--
+		}
+		exponent++
+		mantissa &= 0x3ff
+	case exponent == 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mantissa<<13)
+	}
+
+	return math.Float32frombits(sign | (exponent+127-15)<<23 | mantissa<<13)
+}
+
+// float32At reads a little endian float32 at an offset
+func float32At(src []byte, offset int) float32 {
+	return math.Float32frombits(binary.LittleEndian.Uint32(src[offset:]))
+}
+
+// unitFloatToUint8 maps a float in [0,1] to [0,255], clamping anything outside
+func unitFloatToUint8(f float32) uint8 {
+	if !(f > 0) {
+		return 0
+	}
+	if f >= 1 {
+		return 255
+	}
+
+	return uint8(f*255 + 0.5)
+}
+
+// blueScreen treats pure blue as fully transparent
+func blueScreen(r uint8, g uint8, b uint8) [4]uint8 {
+	if r == 0 && g == 0 && b == 255 {
+		return [4]uint8{0, 0, 0, 0}
+	}
+
+	return [4]uint8{r, g, b, 255}
+}
+
+// expand4 expands the lowest 4 bits of c to 8 bits
+func expand4(c uint16) uint8 {
+	v := uint8(c) & 0x0f
+	return v<<4 | v
+}
+
+// expand5 expands the lowest 5 bits of c to 8 bits
+func expand5(c uint16) uint8 {
+	v := uint8(c) & 0x1f
+	return v<<3 | v>>2
+}
+
+// expand6 expands the lowest 6 bits of c to 8 bits
+func expand6(c uint16) uint8 {
+	v := uint8(c) & 0x3f
+	return v<<2 | v>>4
+}
+
+// pixelEncoder converts 8 bit per channel non-premultiplied RGBA into a single stored pixel
+type pixelEncoder func(rgba [4]uint8, dst []byte)
+
+// uncompressedEncoders are all uncompressed formats that can be encoded from 8 bit per channel colour.
+// Packed formats are little endian, with the first named channel in the lowest bits.
+var uncompressedEncoders = map[format.Format]pixelEncoder{
+	format.RGBA8888: func(c [4]uint8, dst []byte) { dst[0], dst[1], dst[2], dst[3] = c[0], c[1], c[2], c[3] },
+	format.ABGR8888: func(c [4]uint8, dst []byte) { dst[0], dst[1], dst[2], dst[3] = c[3], c[2], c[1], c[0] },
+	format.RGB888:   func(c [4]uint8, dst []byte) { dst[0], dst[1], dst[2] = c[0], c[1], c[2] },
+	format.BGR888:   func(c [4]uint8, dst []byte) { dst[0], dst[1], dst[2] = c[2], c[1], c[0] },
+	format.RGB565: func(c [4]uint8, dst []byte) {
+		binary.LittleEndian.PutUint16(dst, reduce(c[0], 5)|reduce(c[1], 6)<<5|reduce(c[2], 5)<<11)
+	},
+	format.I8:   func(c [4]uint8, dst []byte) { dst[0] = luminance(c) },
+	format.IA88: func(c [4]uint8, dst []byte) { dst[0], dst[1] = luminance(c), c[3] },
+	format.A8:   func(c [4]uint8, dst []byte) { dst[0] = c[3] },
+	format.RGB888BLUESCREEN: func(c [4]uint8, dst []byte) {
+		c = toBlueScreen(c)
+		dst[0], dst[1], dst[2] = c[0], c[1], c[2]
+	},
+	format.BGR888BLUESCREEN: func(c [4]uint8, dst []byte) {
+		c = toBlueScreen(c)
+		dst[0], dst[1], dst[2] = c[2], c[1], c[0]
+	},
+	format.ARGB8888: func(c [4]uint8, dst []byte) { dst[0], dst[1], dst[2], dst[3] = c[3], c[0], c[1], c[2] },
+	format.BGRA8888: func(c [4]uint8, dst []byte) { dst[0], dst[1], dst[2], dst[3] = c[2], c[1], c[0], c[3] },
+	format.BGRX8888: func(c [4]uint8, dst []byte) { dst[0], dst[1], dst[2], dst[3] = c[2], c[1], c[0], 255 },
+	format.BGR565: func(c [4]uint8, dst []byte) {
+		binary.LittleEndian.PutUint16(dst, reduce(c[2], 5)|reduce(c[1], 6)<<5|reduce(c[0], 5)<<11)
+	},
+	format.BGRX5551: func(c [4]uint8, dst []byte) {
+		binary.LittleEndian.PutUint16(dst, reduce(c[2], 5)|reduce(c[1], 5)<<5|reduce(c[0], 5)<<10|1<<15)
+	},
+	format.BGRA4444: func(c [4]uint8, dst []byte) {
+		binary.LittleEndian.PutUint16(dst, reduce(c[2], 4)|reduce(c[1], 4)<<4|reduce(c[0], 4)<<8|reduce(c[3], 4)<<12)
+	},
+	format.BGRA5551: func(c [4]uint8, dst []byte) {
+		binary.LittleEndian.PutUint16(dst, reduce(c[2], 5)|reduce(c[1], 5)<<5|reduce(c[0], 5)<<10|reduce(c[3], 1)<<15)
+	},
+	format.UV88:     func(c [4]uint8, dst []byte) { dst[0], dst[1] = c[0], c[1] },
+	format.UVWQ8888: func(c [4]uint8, dst []byte) { dst[0], dst[1], dst[2], dst[3] = c[0], c[1], c[2], c[3] },
+	format.UVLX8888: func(c [4]uint8, dst []byte) { dst[0], dst[1], dst[2], dst[3] = c[0], c[1], c[2], c[3] },
+}
+
+// EncodeUncompressed encodes an image into an uncompressed format.
+// Returns nil if the format cannot be encoded from 8 bit per channel colour.
+func EncodeUncompressed(img *image.NRGBA, storedFormat format.Format) []byte {
+	encode, ok := uncompressedEncoders[storedFormat]
+	if !ok {
+		return nil
+	}
+
+	size := nrgbaFormats[storedFormat].size
+	if storedFormat == format.I8 || storedFormat == format.A8 {
+		size = 1
+	}
+
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	out := make([]byte, width*height*size)
+	var pixel [4]uint8
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			copy(pixel[:], img.Pix[img.PixOffset(img.Rect.Min.X+x, img.Rect.Min.Y+y):])
+			encode(pixel, out[(y*width+x)*size:])
+		}
+	}
+
+	return out
+}
+
+// reduce rounds an 8 bit channel to the given number of bits
+func reduce(v uint8, bits uint) uint16 {
+	max := uint16(1)<<bits - 1
+	return (uint16(v)*max + 127) / 255
+}
+
+// luminance converts a colour to greyscale with the same weights as image/color
+func luminance(c [4]uint8) uint8 {
+	return uint8((19595*uint32(c[0]) + 38470*uint32(c[1]) + 7471*uint32(c[2]) + 1<<15) >> 16)
+}
+
+// toBlueScreen replaces transparent colours with pure blue
+func toBlueScreen(c [4]uint8) [4]uint8 {
+	if c[3] < 128 {
+		return [4]uint8{0, 0, 255, 255}
+	}
+
+	return c
+}
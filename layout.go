@@ -0,0 +1,289 @@
+package vtf
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/galaco/vtf/format"
+	"github.com/galaco/vtf/internal"
+)
+
+// maxImageDataSize caps the computed size of high resolution image data.
+// Corrupt headers may describe more data than an int64 holds; anything this
+// large exceeds any real file, so fails validation instead of overflowing.
+const maxImageDataSize = math.MaxInt64 / 4
+
+// imageLayout locates every surface of the high resolution image data within a file.
+// Data is stored mipmap by mipmap, smallest first, then frame, face & Z slice.
+type imageLayout struct {
+	// start is the offset of the smallest mipmap
+	start int64
+	// frames & faces are the number of frames & faces of every mipmap
+	frames int
+	faces  int
+	// sizes are the width, height & depth of every mipmap
+	sizes [][3]int
+	// surfaceSizes are the size in bytes of a single slice of every mipmap
+	surfaceSizes []int
+	// mipmapOffsets are the offsets of every mipmap from the start of the file
+	mipmapOffsets []int64
+	// end is the offset immediately after the largest mipmap
+	end int64
+}
+
+// newImageLayout computes where the high resolution mipmaps that options load are stored.
+// In 7.3+ the location is given by its resource entry, and only those mipmaps must fit
+// within the file. Earlier versions have no directory, so the data is assumed to end the file.
+func newImageLayout(header *Header, resources []Resource, fileSize int64, options *ReaderOptions) (*imageLayout, error) {
+	layout := computeImageLayout(header)
+	totalSize := layout.end
+
+	if resource := resourceByTag(resources, ResourceTagHighResImage); resource != nil {
+		start := int64(resource.Offset)
+		if start < int64(header.HeaderSize) {
+			return nil, fieldError(FieldHighResImageData, start, fmt.Errorf("%w: data starts within the %d byte header", ErrorMipmapSizeMismatch, header.HeaderSize))
+		}
+		layout.moveTo(start)
+		layout.truncate(options.mipmapCount(layout.sizes))
+		if layout.end > fileSize {
+			return nil, layout.truncatedError(fileSize)
+		}
+	} else {
+		// Without a resource directory, high resolution data is assumed
+		// to be at the end of the file
+		if totalSize > fileSize-int64(header.HeaderSize) {
+			// Report the surface that is cut short were the data to follow the header
+			layout.moveTo(int64(header.HeaderSize))
+			return nil, layout.truncatedError(fileSize)
+		}
+		layout.moveTo(fileSize - totalSize)
+		layout.truncate(options.mipmapCount(layout.sizes))
+	}
+
+	return layout, nil
+}
+
+// salvageImageLayout locates the mipmaps of a truncated file that fit entirely within it.
+// Without a resource directory the data is assumed to follow the thumbnail, rather than
+// end the file. Also returns the problem with the whole of the data
+func salvageImageLayout(header *Header, resources []Resource, fileSize int64) (*imageLayout, *ParseError) {
+	layout := computeImageLayout(header)
+	start := int64(header.HeaderSize) + int64(lowResImageDataSize(header))
+	if resource := resourceByTag(resources, ResourceTagHighResImage); resource != nil {
+		start = int64(resource.Offset)
+	}
+	if start < int64(header.HeaderSize) || start > fileSize {
+		layout.moveTo(int64(header.HeaderSize))
+		layout.truncate(0)
+		return layout, fieldError(FieldHighResImageData, start, fmt.Errorf("%w: data at offset %d is outside of the file", ErrorMipmapSizeMismatch, start))
+	}
+	layout.moveTo(start)
+
+	problem := layout.truncatedError(fileSize)
+	count := 0
+	for count < len(layout.sizes) && layout.mipmapEnd(count) <= fileSize {
+		count++
+	}
+	layout.truncate(count)
+
+	return layout, problem
+}
+
+// overlapsLowResImage returns whether high resolution data assumed to end the file
+// would overlap the thumbnail; a sign that the file is truncated
+func overlapsLowResImage(header *Header, resources []Resource, layout *imageLayout) bool {
+	if layout == nil || resourceByTag(resources, ResourceTagHighResImage) != nil {
+		return false
+	}
+
+	return layout.start < int64(header.HeaderSize)+int64(lowResImageDataSize(header))
+}
+
+// computeImageLayout computes the size & relative position of every surface
+// of the high resolution image data, as if it started at offset 0
+func computeImageLayout(header *Header) *imageLayout {
+	storedFormat := format.Format(header.HighResImageFormat)
+	layout := &imageLayout{
+		frames: int(header.Frames),
+		faces:  header.FaceCount(),
+		sizes:  internal.ComputeMipmapSizes(int(header.MipmapCount), int(header.Width), int(header.Height), header.SliceCount()),
+	}
+
+	// Work out the total size of all high resolution data
+	layout.surfaceSizes = make([]int, len(layout.sizes))
+	layout.mipmapOffsets = make([]int64, len(layout.sizes))
+	for mipmapIdx, size := range layout.sizes {
+		layout.surfaceSizes[mipmapIdx] = internal.ComputeSizeOfMipmapData(size[0], size[1], storedFormat)
+		layout.mipmapOffsets[mipmapIdx] = layout.end
+		surfaceSize := int64(layout.surfaceSizes[mipmapIdx])
+		surfaces := int64(layout.frames) * int64(layout.faces) * int64(size[2])
+		if surfaces > 0 && surfaceSize > (maxImageDataSize-layout.end)/surfaces {
+			layout.end = maxImageDataSize
+			continue
+		}
+		layout.end += surfaceSize * surfaces
+	}
+
+	return layout
+}
+
+// moveTo relocates the high resolution image data to start at an offset
+func (layout *imageLayout) moveTo(start int64) {
+	delta := start - layout.start
+	for mipmapIdx := range layout.mipmapOffsets {
+		layout.mipmapOffsets[mipmapIdx] += delta
+	}
+	layout.start += delta
+	layout.end += delta
+}
+
+// mipmapEnd returns the offset immediately after a mipmap
+func (layout *imageLayout) mipmapEnd(mipmap int) int64 {
+	if mipmap+1 < len(layout.mipmapOffsets) {
+		return layout.mipmapOffsets[mipmap+1]
+	}
+
+	return layout.end
+}
+
+// truncate drops every mipmap larger than the smallest count
+func (layout *imageLayout) truncate(count int) {
+	if count >= len(layout.sizes) {
+		return
+	}
+
+	layout.end = layout.mipmapOffsets[count]
+	layout.sizes = layout.sizes[:count]
+	layout.surfaceSizes = layout.surfaceSizes[:count]
+	layout.mipmapOffsets = layout.mipmapOffsets[:count]
+}
+
+// locate returns the surface containing an offset, and the offset that surface starts at.
+// Returns false if no surface contains the offset
+func (layout *imageLayout) locate(offset int64) (mipmap int, frame int, face int, slice int, start int64, ok bool) {
+	if offset < layout.start || offset >= layout.end {
+		return 0, 0, 0, 0, 0, false
+	}
+
+	mipmap = len(layout.sizes) - 1
+	for mipmap > 0 && layout.mipmapOffsets[mipmap] > offset {
+		mipmap--
+	}
+	size := int64(layout.surfaceSizes[mipmap])
+	if size == 0 {
+		return 0, 0, 0, 0, 0, false
+	}
+
+	idx := (offset - layout.mipmapOffsets[mipmap]) / size
+	slices := int64(layout.sizes[mipmap][2])
+	frame = int(idx / (slices * int64(layout.faces)))
+	face = int(idx / slices % int64(layout.faces))
+	slice = int(idx % slices)
+
+	return mipmap, frame, face, slice, layout.mipmapOffsets[mipmap] + idx*size, true
+}
+
+// truncatedError reports the first surface that does not fit within a file
+func (layout *imageLayout) truncatedError(fileSize int64) *ParseError {
+	err := fmt.Errorf("%w: %d bytes of image data exceed file size %d", ErrorMipmapSizeMismatch, layout.end-layout.start, fileSize)
+	mipmap, frame, face, slice, start, ok := layout.locate(fileSize)
+	if !ok {
+		return fieldError(FieldHighResImageData, layout.start, err)
+	}
+
+	return surfaceError(mipmap, frame, face, slice, start, err)
+}
+
+// surfaceOffset returns the offset & size of a single slice of a mipmap, frame & face.
+// Returns false if the surface does not exist
+func (layout *imageLayout) surfaceOffset(mipmap int, frame int, face int, slice int) (int64, int, bool) {
+	if mipmap < 0 || mipmap >= len(layout.sizes) ||
+		frame < 0 || frame >= layout.frames ||
+		face < 0 || face >= layout.faces ||
+		slice < 0 || slice >= layout.sizes[mipmap][2] {
+		return 0, 0, false
+	}
+
+	size := layout.surfaceSizes[mipmap]
+	idx := (frame*layout.faces+face)*layout.sizes[mipmap][2] + slice
+
+	return layout.mipmapOffsets[mipmap] + int64(idx)*int64(size), size, true
+}
+
+// readSurface reads a single slice of a mipmap, frame & face from a source
+func (layout *imageLayout) readSurface(source io.ReaderAt, mipmap int, frame int, face int, slice int) ([]uint8, error) {
+	offset, size, ok := layout.surfaceOffset(mipmap, frame, face, slice)
+	if !ok {
+		return nil, fmt.Errorf("%w: mipmap %d, frame %d, face %d, slice %d does not exist", ErrorInvalidDimensions, mipmap, frame, face, slice)
+	}
+
+	data := make([]uint8, size)
+	if err := readAt(source, data, offset); err != nil {
+		return nil, surfaceError(mipmap, frame, face, slice, offset, fmt.Errorf("%w: %s", ErrorMipmapSizeMismatch, err))
+	}
+
+	return data, nil
+}
+
+// readMipmaps reads all mipmaps from a source in a single read
+func (layout *imageLayout) readMipmaps(source io.ReaderAt) ([][][][][]uint8, error) {
+	buffer := make([]uint8, layout.end-layout.start)
+	if err := readAt(source, buffer, layout.start); err != nil {
+		return nil, fieldError(FieldHighResImageData, layout.start, fmt.Errorf("%w: %s", ErrorMipmapSizeMismatch, err))
+	}
+
+	return layout.splitMipmaps(buffer), nil
+}
+
+// splitMipmaps divides all high resolution data into surfaces, without copying.
+// Returned format is a bit odd, but is just a set of flat arrays containing arrays:
+// mipmap[frame[face[slice[RGBA]]]
+func (layout *imageLayout) splitMipmaps(buffer []uint8) [][][][][]uint8 {
+	// Iterate mipmap; smallest to largest
+	bufferOffset := 0
+	mipMaps := make([][][][][]uint8, len(layout.sizes))
+	for mipmapIdx := range mipMaps {
+		bufferSize := layout.surfaceSizes[mipmapIdx]
+		numZSlice := layout.sizes[mipmapIdx][2]
+		// Frame by frame; first to last
+		frames := make([][][][]uint8, layout.frames)
+		for frameIdx := range frames {
+			faces := make([][][]uint8, layout.faces)
+			// Face by face; first to last
+			for faceIdx := range faces {
+				zSlices := make([][]uint8, numZSlice)
+				// Z Slice by Z Slice; first to last
+				// Volume textures store a slice per depth layer, halving every mipmap
+				for sliceIdx := range zSlices {
+					zSlices[sliceIdx] = buffer[bufferOffset : bufferOffset+bufferSize : bufferOffset+bufferSize]
+					bufferOffset += bufferSize
+				}
+				faces[faceIdx] = zSlices
+			}
+			frames[frameIdx] = faces
+		}
+		mipMaps[mipmapIdx] = frames
+	}
+
+	return mipMaps
+}
+
+// readAt reads exactly len(buffer) bytes from a source at an offset.
+// Empty reads always succeed, even at the end of the source. A full read is
+// successful even if the source also reports io.EOF, as io.ReaderAt allows;
+// short reads are io.ErrUnexpectedEOF
+func readAt(source io.ReaderAt, buffer []byte, offset int64) error {
+	if len(buffer) == 0 {
+		return nil
+	}
+	n, err := source.ReadAt(buffer, offset)
+	if n == len(buffer) {
+		return nil
+	}
+	if err == nil || err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return err
+}
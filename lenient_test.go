@@ -0,0 +1,152 @@
+package vtf
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// writeTestVtf writes a texture, returning the file & its layout
+func writeTestVtf(t *testing.T, v *Vtf) ([]byte, *imageLayout) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, v); err != nil {
+		t.Fatal(err)
+	}
+	lazy, err := ReadFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes(), lazy.layout
+}
+
+// checkWarnings ensures a vtf has a single warning wrapping an error
+func checkWarnings(t *testing.T, v *Vtf, expected error) *ParseError {
+	t.Helper()
+	warnings := v.Warnings()
+	if len(warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %v", warnings)
+	}
+	if !errors.Is(warnings[0], expected) || warnings[0].Severity != SeverityWarning {
+		t.Errorf("expected a warning wrapping %v, got %v", expected, warnings[0])
+	}
+
+	return warnings[0]
+}
+
+func TestReadFromStream_LenientTruncated(t *testing.T) {
+	for _, version := range []uint32{2, 5} {
+		expected := newTestVtf(version, 3)
+		data, layout := writeTestVtf(t, expected)
+		written, err := ReadFromStream(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Cut the file part way through frame 1 of mipmap 2
+		truncated := data[:layout.mipmapOffsets[2]+int64(layout.surfaceSizes[2])+5]
+
+		if _, err := ReadFromStream(bytes.NewReader(truncated)); err == nil {
+			t.Errorf("7.%d: expected an error without WithLenient", version)
+		}
+
+		v, err := ReadFromStream(bytes.NewReader(truncated), WithLenient())
+		if err != nil {
+			t.Fatalf("7.%d: %s", version, err)
+		}
+		if v.Header() != written.Header() || !bytes.Equal(v.LowResImageData(), expected.LowResImageData()) {
+			t.Errorf("7.%d: header or thumbnail differs from the written texture", version)
+		}
+		if v.LoadedMipmapCount() != 2 || !reflect.DeepEqual(v.HighResImageData(), expected.HighResImageData()[:2]) {
+			t.Errorf("7.%d: expected the 2 smallest mipmaps, got %d", version, v.LoadedMipmapCount())
+		}
+		warning := checkWarnings(t, v, ErrorMipmapSizeMismatch)
+		if warning.Mip != 2 || warning.Frame != 1 {
+			t.Errorf("7.%d: unexpected warning location: %v", version, warning)
+		}
+
+		lazy, err := ReadFromReaderAt(bytes.NewReader(truncated), int64(len(truncated)), WithLenient())
+		if err != nil {
+			t.Fatalf("7.%d: %s", version, err)
+		}
+		if !reflect.DeepEqual(lazy.HighResImageData(), expected.HighResImageData()[:2]) {
+			t.Errorf("7.%d: lazily read mipmaps differ from the written texture", version)
+		}
+		checkWarnings(t, lazy, ErrorMipmapSizeMismatch)
+	}
+}
+
+func TestReadFromStream_LenientThumbnail(t *testing.T) {
+	data, _ := writeTestVtf(t, newTestVtf(5, 1))
+	header, err := ReadHeaderFromStream(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	truncated := data[:header.HeaderSize+2]
+
+	v, err := ReadFromStream(bytes.NewReader(truncated), WithLenient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Header() != *header || v.LowResImageData() != nil || v.LoadedMipmapCount() != 0 {
+		t.Error("expected only the header")
+	}
+	if len(v.Warnings()) != 2 || v.Warnings()[0].Field != FieldLowResImageData || v.Warnings()[1].Field != FieldHighResImageData {
+		t.Errorf("expected thumbnail & mipmap warnings, got %v", v.Warnings())
+	}
+}
+
+func TestReadFromStream_LenientResource(t *testing.T) {
+	v := newTestVtf(5, 1)
+	v.SetResource(Resource{Tag: ResourceTagKeyValues, Data: []byte("key value")})
+	v.SetResource(Resource{Tag: ResourceTagCRC, Flags: ResourceFlagNoDataChunk, Data: []byte{1, 2, 3, 4}})
+	data, _ := writeTestVtf(t, v)
+	written, err := ReadFromStream(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset := written.Resource(ResourceTagKeyValues).Offset
+	data[offset], data[offset+1], data[offset+2], data[offset+3] = 0xff, 0xff, 0xff, 0xff
+
+	lenient, err := ReadFromStream(bytes.NewReader(data), WithLenient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lenient.Resource(ResourceTagKeyValues) != nil || lenient.Resource(ResourceTagCRC) == nil {
+		t.Error("expected only the unreadable resource to be dropped")
+	}
+	if !reflect.DeepEqual(lenient.HighResImageData(), v.HighResImageData()) {
+		t.Error("mipmaps differ from the written texture")
+	}
+	if checkWarnings(t, lenient, ErrorInvalidResource).Field != `Resource "KVD"` {
+		t.Errorf("unexpected warning: %v", lenient.Warnings())
+	}
+}
+
+func TestStreamReader_LenientTruncated(t *testing.T) {
+	expected := newTestVtf(5, 3)
+	data, layout := writeTestVtf(t, expected)
+	truncated := data[:layout.mipmapOffsets[2]+int64(layout.surfaceSizes[2])+5]
+
+	streamReader, err := NewStreamReader(bytes.NewReader(truncated), WithLenient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	surfaces := 0
+	err = streamReader.ReadSurfaces(func(mipmap int, frame int, face int, slice int, data []byte) error {
+		if !bytes.Equal(data, expected.Slice(mipmap, frame, face, slice)) {
+			t.Errorf("surface %d %d %d %d differs from the written texture", mipmap, frame, face, slice)
+		}
+		surfaces++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every frame of the 2 smallest mipmaps, and the first frame of the next
+	if surfaces != 7 {
+		t.Errorf("expected 7 surfaces, got %d", surfaces)
+	}
+	checkWarnings(t, streamReader.Vtf(), ErrorMipmapSizeMismatch)
+}
@@ -0,0 +1,77 @@
+package vtf
+
+import (
+	"image"
+
+	"github.com/galaco/vtf/internal"
+)
+
+// MipmapFilter selects the resampling filter used to generate mipmaps
+type MipmapFilter int
+
+const (
+	// MipmapFilterBox averages the pixels each mipmap pixel covers. Fast, but soft
+	MipmapFilterBox = MipmapFilter(0)
+	// MipmapFilterTriangle is a linear (tent) filter
+	MipmapFilterTriangle = MipmapFilter(1)
+	// MipmapFilterKaiser is a Kaiser windowed sinc. Sharp, with little ringing
+	MipmapFilterKaiser = MipmapFilter(2)
+	// MipmapFilterLanczos is a 3 lobe Lanczos windowed sinc. Sharpest, but may ring
+	MipmapFilterLanczos = MipmapFilter(3)
+)
+
+// MipmapOptions configures mipmap generation
+type MipmapOptions struct {
+	// Filter used to resample each mipmap. Defaults to MipmapFilterBox
+	Filter MipmapFilter
+	// SRGB marks colour as sRGB encoded, so it is filtered in linear light.
+	// Alpha is always filtered as-is
+	SRGB bool
+}
+
+// resampleFilter returns the internal filter for the selected MipmapFilter
+func (options *MipmapOptions) resampleFilter() internal.ResampleFilter {
+	if options == nil {
+		return internal.FilterBox
+	}
+
+	switch options.Filter {
+	case MipmapFilterTriangle:
+		return internal.FilterTriangle
+	case MipmapFilterKaiser:
+		return internal.FilterKaiser
+	case MipmapFilterLanczos:
+		return internal.FilterLanczos
+	}
+
+	return internal.FilterBox
+}
+
+// GenerateMipmaps generates the full mip chain of an image, down to 1x1, in the
+// sizes a vtf stores them. Smallest mipmap is first, and the last is the image itself.
+// Each mipmap is resampled directly from the image. If flags contains FlagNoMipmaps
+// only the image itself is returned.
+func GenerateMipmaps(img image.Image, flags uint32, options *MipmapOptions) []*image.NRGBA {
+	source := toNRGBA(img)
+	width, height := source.Rect.Dx(), source.Rect.Dy()
+
+	count := 1
+	if flags&FlagNoMipmaps == 0 {
+		count = internal.MipmapCount(width, height, 1)
+	}
+
+	filter := options.resampleFilter()
+	srgb := options != nil && options.SRGB
+
+	sizes := internal.ComputeMipmapSizes(count, width, height, 1)
+	mipmaps := make([]*image.NRGBA, len(sizes))
+	for idx, size := range sizes {
+		if size[0] == width && size[1] == height {
+			mipmaps[idx] = source
+			continue
+		}
+		mipmaps[idx] = internal.Resize(source, size[0], size[1], filter, srgb)
+	}
+
+	return mipmaps
+}
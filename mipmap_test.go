@@ -0,0 +1,70 @@
+package vtf
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestGenerateMipmaps_Sizes(t *testing.T) {
+	mipmaps := GenerateMipmaps(gradientImage(13, 5), 0, nil)
+
+	expected := [][2]int{{1, 1}, {3, 1}, {6, 2}, {13, 5}}
+	if len(mipmaps) != len(expected) {
+		t.Fatalf("expected %d mipmaps, got %d", len(expected), len(mipmaps))
+	}
+	for idx, size := range expected {
+		if b := mipmaps[idx].Bounds(); b.Dx() != size[0] || b.Dy() != size[1] {
+			t.Errorf("mipmap %d: expected %dx%d, got %dx%d", idx, size[0], size[1], b.Dx(), b.Dy())
+		}
+	}
+}
+
+func TestGenerateMipmaps_NoMipmaps(t *testing.T) {
+	mipmaps := GenerateMipmaps(gradientImage(16, 16), FlagNoMipmaps, nil)
+	if len(mipmaps) != 1 || mipmaps[0].Bounds().Dx() != 16 {
+		t.Errorf("expected only the top level")
+	}
+}
+
+func TestGenerateMipmaps_Filters(t *testing.T) {
+	solid := solidImage(16, 8, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+
+	for _, filter := range []MipmapFilter{MipmapFilterBox, MipmapFilterTriangle, MipmapFilterKaiser, MipmapFilterLanczos} {
+		for _, srgb := range []bool{false, true} {
+			mipmaps := GenerateMipmaps(solid, 0, &MipmapOptions{Filter: filter, SRGB: srgb})
+			for idx, mipmap := range mipmaps {
+				if c := mipmap.NRGBAAt(0, 0); c != solid.NRGBAAt(0, 0) {
+					t.Errorf("filter %d srgb %t mipmap %d: expected solid colour to be preserved, got %v", filter, srgb, idx, c)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateMipmaps_LinearLight(t *testing.T) {
+	checkerboard := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	checkerboard.SetNRGBA(0, 0, color.NRGBA{255, 255, 255, 255})
+	checkerboard.SetNRGBA(1, 0, color.NRGBA{0, 0, 0, 255})
+	checkerboard.SetNRGBA(0, 1, color.NRGBA{0, 0, 0, 255})
+	checkerboard.SetNRGBA(1, 1, color.NRGBA{255, 255, 255, 255})
+
+	gamma := GenerateMipmaps(checkerboard, 0, nil)[0].NRGBAAt(0, 0)
+	if gamma.R < 127 || gamma.R > 128 {
+		t.Errorf("expected average of 127-128 in gamma space, got %d", gamma.R)
+	}
+	linear := GenerateMipmaps(checkerboard, 0, &MipmapOptions{SRGB: true})[0].NRGBAAt(0, 0)
+	if linear.R != 188 {
+		t.Errorf("expected average of 188 in linear light, got %d", linear.R)
+	}
+}
+
+func TestGenerateMipmaps_TransparentPixelsDoNotBleed(t *testing.T) {
+	img := solidImage(2, 2, color.NRGBA{R: 255, A: 255})
+	img.SetNRGBA(1, 1, color.NRGBA{G: 255, A: 0})
+
+	c := GenerateMipmaps(img, 0, &MipmapOptions{Filter: MipmapFilterTriangle})[0].NRGBAAt(0, 0)
+	if c.R != 255 || c.G != 0 || c.A != 191 {
+		t.Errorf("unexpected colour %v", c)
+	}
+}
@@ -7,17 +7,70 @@ import (
 
 // ReadFromStream loads a vtf from standard
 // io.Reader stream
-func ReadFromStream(stream io.Reader) (*Vtf, error) {
+func ReadFromStream(stream io.Reader, options ...ReadOption) (*Vtf, error) {
 	reader := &Reader{
-		stream: stream,
+		stream:  stream,
+		options: newReaderOptions(options),
 	}
 
 	return reader.Read()
 }
 
-// ReadFromFile is a wrapper for ReadFromStream wrapper to load directly from
-// filesystem. Exists for convenience
-func ReadFromFile(filepath string) (*Vtf, error) {
+// ReadFromReaderAt loads a vtf from a random access source of a known size,
+// such as an *os.File or a memory mapped archive. Only the Header, resources and
+// low resolution thumbnail are read up front. High resolution mipmaps are read
+// from the source whenever they are accessed, so the source must remain open
+// for as long as the vtf is used.
+func ReadFromReaderAt(source io.ReaderAt, size int64, options ...ReadOption) (*Vtf, error) {
+	reader := &Reader{
+		options: newReaderOptions(options),
+	}
+
+	vtf, layout, err := reader.readStructure(source, size)
+	if err != nil {
+		return nil, err
+	}
+	vtf.source = source
+	vtf.layout = layout
+
+	return vtf, nil
+}
+
+// ReadFromFile loads a vtf directly from filesystem. Exists for convenience.
+// Unlike ReadFromStream, the file is read no further than the mipmaps that are loaded
+func ReadFromFile(filepath string, options ...ReadOption) (*Vtf, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	reader := &Reader{
+		options: newReaderOptions(options),
+	}
+
+	return reader.readFrom(file, info.Size())
+}
+
+// ReadHeaderFromStream reads only the header of a vtf from a standard
+// io.Reader stream. Only HeaderSize bytes are consumed.
+func ReadHeaderFromStream(stream io.Reader, options ...ReadOption) (*Header, error) {
+	reader := &Reader{
+		stream:  stream,
+		options: newReaderOptions(options),
+	}
+
+	return reader.ReadHeader()
+}
+
+// ReadHeaderFromFile is a wrapper for ReadHeaderFromStream to read a header
+// directly from filesystem. Exists for convenience
+func ReadHeaderFromFile(filepath string, options ...ReadOption) (*Header, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
 		return nil, err
@@ -25,6 +78,5 @@ func ReadFromFile(filepath string) (*Vtf, error) {
 
 	defer file.Close()
 
-	v, err := ReadFromStream(file)
-	return v, err
+	return ReadHeaderFromStream(file, options...)
 }
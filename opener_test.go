@@ -1,8 +1,15 @@
 package vtf
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
 	"os"
+	"reflect"
 	"testing"
+
+	"github.com/galaco/vtf/format"
 )
 
 func TestReadFromFile(t *testing.T) {
@@ -24,3 +31,303 @@ func TestReadFromStream(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestReadFromStream_OddDimensions(t *testing.T) {
+	// Mipmaps halve rounding down: 5x3, 2x1 & 1x1, rather than 5x3, 3x2 & 2x1
+	header := newTestVtf(2, 1).Header()
+	header.Width, header.Height = 5, 3
+	header.MipmapCount = 3
+	v := newTestVtfFromHeader(header)
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, v); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadFromStream(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without a resource directory, mipmaps are located from the end of the file
+	expected := []int{4, 8, 60}
+	if buf.Len() != int(read.Header().HeaderSize)+len(read.LowResImageData())+4+8+60 {
+		t.Errorf("expected %d bytes of mipmaps to end the file", 4+8+60)
+	}
+	for mipmap, size := range expected {
+		data := read.HighResImageData()[mipmap][0][0][0]
+		if len(data) != size {
+			t.Errorf("mipmap %d: expected %d bytes, got %d", mipmap, size, len(data))
+		}
+		if !bytes.Equal(data, v.HighResImageData()[mipmap][0][0][0]) {
+			t.Errorf("mipmap %d: data differs from the written texture", mipmap)
+		}
+	}
+}
+
+// countingReaderAt records how many bytes are read from a source, and how far into it
+type countingReaderAt struct {
+	source   io.ReaderAt
+	read     int
+	furthest int64
+}
+
+func (reader *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := reader.source.ReadAt(p, off)
+	reader.read += n
+	if end := off + int64(n); n > 0 && end > reader.furthest {
+		reader.furthest = end
+	}
+	return n, err
+}
+
+// countingReader records how many bytes are read from a stream
+type countingReader struct {
+	stream io.Reader
+	read   int64
+}
+
+func (reader *countingReader) Read(p []byte) (int, error) {
+	n, err := reader.stream.Read(p)
+	reader.read += int64(n)
+	return n, err
+}
+
+func TestReadFromReaderAt(t *testing.T) {
+	f, err := os.Open("samples/read/test.vtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := &countingReaderAt{source: f}
+	lazy, err := ReadFromReaderAt(source, info.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(source.read) >= info.Size()/2 {
+		t.Errorf("expected only the header & thumbnail to be read, read %d of %d bytes", source.read, info.Size())
+	}
+
+	eager, err := ReadFromFile("samples/read/test.vtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lazy.Header() != eager.Header() || !bytes.Equal(lazy.LowResImageData(), eager.LowResImageData()) {
+		t.Error("header or thumbnail differs from ReadFromFile")
+	}
+	if !reflect.DeepEqual(lazy.HighResImageData(), eager.HighResImageData()) {
+		t.Error("mipmap data differs from ReadFromFile")
+	}
+}
+
+func TestReadFromReaderAt_Surfaces(t *testing.T) {
+	header := newTestVtf(5, 3).Header()
+	header.Flags |= FlagEnvironmentMap
+	v := newTestVtfFromHeader(header)
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, v); err != nil {
+		t.Fatal(err)
+	}
+
+	source := &countingReaderAt{source: bytes.NewReader(buf.Bytes())}
+	lazy, err := ReadFromReaderAt(source, int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := source.read
+	data, err := lazy.SliceData(2, 1, CubemapFaceUp, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, v.Face(2, 1, CubemapFaceUp)) {
+		t.Error("face data differs from the written texture")
+	}
+	if source.read-before != len(data) {
+		t.Errorf("expected to read %d bytes, read %d", len(data), source.read-before)
+	}
+
+	if _, err := lazy.SliceData(4, 0, 0, 0); !errors.Is(err, ErrorInvalidDimensions) {
+		t.Errorf("expected %v, got %v", ErrorInvalidDimensions, err)
+	}
+}
+
+func TestReadFromReaderAt_OddDimensions(t *testing.T) {
+	// Mipmaps halve rounding down, so odd dimensions shrink faster than when rounding up
+	tests := []struct {
+		format        format.Format
+		width, height uint16
+		sizes         [][2]int
+		surfaceSizes  []int
+	}{
+		{format.Dxt5, 6, 3, [][2]int{{1, 1}, {3, 1}, {6, 3}}, []int{16, 16, 32}},
+		{format.RGBA8888, 5, 3, [][2]int{{1, 1}, {2, 1}, {5, 3}}, []int{4, 8, 60}},
+	}
+
+	for _, tt := range tests {
+		header := newTestVtf(5, 1).Header()
+		header.Width, header.Height = tt.width, tt.height
+		header.MipmapCount = 3
+		header.HighResImageFormat = uint32(tt.format)
+		v := newTestVtfFromHeader(header)
+		var buf bytes.Buffer
+		if err := WriteToStream(&buf, v); err != nil {
+			t.Fatal(err)
+		}
+
+		lazy, err := ReadFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		offset := lazy.layout.start
+		for mipmap, size := range tt.sizes {
+			surfaceOffset, surfaceSize, ok := lazy.layout.surfaceOffset(mipmap, 0, 0, 0)
+			if !ok || surfaceOffset != offset || surfaceSize != tt.surfaceSizes[mipmap] {
+				t.Errorf("%s mipmap %d: expected %d bytes at offset %d, got %d at %d", tt.format, mipmap, tt.surfaceSizes[mipmap], offset, surfaceSize, surfaceOffset)
+			}
+			offset += int64(tt.surfaceSizes[mipmap])
+
+			surface, err := lazy.Surface(mipmap, 0, 0, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if surface.Width != size[0] || surface.Height != size[1] {
+				t.Errorf("%s mipmap %d: expected %dx%d, got %dx%d", tt.format, mipmap, size[0], size[1], surface.Width, surface.Height)
+			}
+			if !bytes.Equal(surface.Data, v.Slice(mipmap, 0, 0, 0)) {
+				t.Errorf("%s mipmap %d: data differs from the written texture", tt.format, mipmap)
+			}
+		}
+		if offset != int64(buf.Len()) {
+			t.Errorf("%s: expected mipmaps to end the file at %d, ended at %d", tt.format, buf.Len(), offset)
+		}
+	}
+}
+
+// eofReaderAt returns io.EOF alongside reads that end at the end of the source,
+// as io.ReaderAt allows
+type eofReaderAt struct {
+	data []byte
+}
+
+func (reader *eofReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := bytes.NewReader(reader.data).ReadAt(p, off)
+	if err == nil && off+int64(n) == int64(len(reader.data)) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func TestReadFromReaderAt_EOFOnFullRead(t *testing.T) {
+	data, err := os.ReadFile("samples/read/test.vtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	eager, err := ReadFromStream(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lazy, err := ReadFromReaderAt(&eofReaderAt{data: data}, int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The largest mipmap ends the file
+	largest := int(lazy.Header().MipmapCount) - 1
+	slice, err := lazy.SliceData(largest, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(slice, eager.Slice(largest, 0, 0, 0)) {
+		t.Error("mipmap data differs from ReadFromStream")
+	}
+	if !reflect.DeepEqual(lazy.HighResImageData(), eager.HighResImageData()) {
+		t.Error("mipmap data differs from ReadFromStream")
+	}
+
+	// Short reads are still errors
+	if _, err := (&eofReaderAt{data: data[:len(data)-1]}).ReadAt(make([]byte, 2), int64(len(data)-2)); err != io.EOF {
+		t.Fatalf("expected the test reader to report %v, got %v", io.EOF, err)
+	}
+	if err := readAt(&eofReaderAt{data: data[:len(data)-1]}, make([]byte, 2), int64(len(data)-2)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+}
+
+func TestReadFromReaderAt_Truncated(t *testing.T) {
+	data, err := os.ReadFile("samples/read/test.vtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Mipmaps are read on demand, so a short source fails when they are accessed
+	v, err := ReadFromReaderAt(bytes.NewReader(data), int64(len(data))+64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.SliceData(int(v.Header().MipmapCount)-1, 0, 0, 0); !errors.Is(err, ErrorMipmapSizeMismatch) {
+		t.Errorf("expected %v, got %v", ErrorMipmapSizeMismatch, err)
+	}
+	if v.HighResImageData() != nil {
+		t.Error("expected no mipmap data")
+	}
+}
+
+func TestReadHeaderFromFile(t *testing.T) {
+	header, err := ReadHeaderFromFile("samples/read/test.vtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := ReadFromFile("samples/read/test.vtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *header != v.Header() {
+		t.Errorf("header differs from ReadFromFile")
+	}
+}
+
+func TestReadHeaderFromStream(t *testing.T) {
+	for _, version := range []uint32{1, 2, 3, 5} {
+		v := newTestVtf(version, 2)
+		v.SetResource(Resource{Tag: ResourceTagKeyValues, Data: []byte("key value")})
+		var buf bytes.Buffer
+		if err := WriteToStream(&buf, v); err != nil {
+			t.Fatal(err)
+		}
+		expected, err := ReadFromStream(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stream := bytes.NewReader(buf.Bytes())
+		header, err := ReadHeaderFromStream(stream)
+		if err != nil {
+			t.Fatalf("7.%d: %s", version, err)
+		}
+		if *header != expected.Header() {
+			t.Errorf("7.%d: header differs from ReadFromStream", version)
+		}
+		if consumed := int(stream.Size()) - stream.Len(); consumed != int(header.HeaderSize) {
+			t.Errorf("7.%d: expected to consume %d bytes, consumed %d", version, header.HeaderSize, consumed)
+		}
+	}
+}
+
+func TestReadHeaderFromStream_Invalid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, newTestVtf(5, 1)); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	binary.LittleEndian.PutUint32(data[headerOffset("HeaderSize"):], 0x7fffffff)
+
+	if _, err := ReadHeaderFromStream(bytes.NewReader(data)); !errors.Is(err, ErrorInvalidHeaderSize) {
+		t.Errorf("expected %v, got %v", ErrorInvalidHeaderSize, err)
+	}
+	if _, err := ReadHeaderFromStream(bytes.NewReader(data[:10])); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+}
@@ -0,0 +1,163 @@
+package vtf
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// defaultDimensionLimit is the default largest width, height & depth.
+	// Source Engine typically uses max 4096x4096; this allows some headroom beyond
+	defaultDimensionLimit = 16384
+	// defaultFrameLimit is the default largest number of frames
+	defaultFrameLimit = 1024
+	// defaultMinHeaderSize & defaultMaxHeaderSize are the default range of header sizes
+	defaultMinHeaderSize = 64
+	defaultMaxHeaderSize = 1024
+	// defaultLowResDimensionLimit is the default largest thumbnail width & height
+	defaultLowResDimensionLimit = 16
+)
+
+var (
+	// ErrorMemoryBudgetExceeded occurs when reading a vtf would hold more memory than its budget allows
+	ErrorMemoryBudgetExceeded = errors.New("memory budget exceeded")
+)
+
+// ReaderOptions configures how a vtf is read, and the limits it is validated against.
+// Limits protect against excessive memory allocation by malicious files.
+type ReaderOptions struct {
+	// MaxMipLevel is the index of the largest mipmap to load. Mipmaps are indexed
+	// smallest to largest, as in HighResImageData. Negative loads every mipmap
+	MaxMipLevel int
+	// MaxDimension is the largest width or height of a mipmap to load. 0 loads
+	// every mipmap. The smallest mipmap is always loaded
+	MaxDimension int
+
+	// DimensionLimit is the largest accepted width, height & depth. Defaults to 16384
+	DimensionLimit int
+	// FrameLimit is the largest accepted number of frames. Defaults to 1024
+	FrameLimit int
+	// MinHeaderSize & MaxHeaderSize are the accepted range of HeaderSize. Default to 64-1024
+	MinHeaderSize int
+	MaxHeaderSize int
+	// LowResDimensionLimit is the largest accepted thumbnail width & height. Defaults to 16
+	LowResDimensionLimit int
+	// MemoryBudget is the most bytes a read may hold in memory. Read & ReadFromStream hold the
+	// file up to the end of the loaded mipmaps, or the whole file if anything follows them;
+	// ReadFromFile holds the loaded mipmaps. Every read holds resource data and the thumbnail.
+	// 0 is unlimited, which is the default
+	MemoryBudget int64
+
+	// Lenient reads as much of a damaged file as possible, rather than failing.
+	// Problems with resources, the thumbnail or mipmaps become warnings; see Vtf.Warnings.
+	// Only the mipmaps that fit entirely within a truncated file are loaded.
+	// A Header or resource directory that cannot be read or fails validation is still an error
+	Lenient bool
+}
+
+// ReadOption sets a single reader option
+type ReadOption func(options *ReaderOptions)
+
+// WithMaxMipLevel only loads mipmaps up to and including an index.
+// Mipmaps are indexed smallest to largest, so 0 loads only the smallest
+func WithMaxMipLevel(mipmap int) ReadOption {
+	return func(options *ReaderOptions) {
+		options.MaxMipLevel = mipmap
+	}
+}
+
+// WithMaxDimension only loads mipmaps whose width & height are both at most
+// a number of pixels
+func WithMaxDimension(pixels int) ReadOption {
+	return func(options *ReaderOptions) {
+		options.MaxDimension = pixels
+	}
+}
+
+// WithDimensionLimit sets the largest accepted width, height & depth
+func WithDimensionLimit(pixels int) ReadOption {
+	return func(options *ReaderOptions) {
+		options.DimensionLimit = pixels
+	}
+}
+
+// WithFrameLimit sets the largest accepted number of frames
+func WithFrameLimit(frames int) ReadOption {
+	return func(options *ReaderOptions) {
+		options.FrameLimit = frames
+	}
+}
+
+// WithHeaderSizeLimits sets the accepted range of HeaderSize, in bytes
+func WithHeaderSizeLimits(min int, max int) ReadOption {
+	return func(options *ReaderOptions) {
+		options.MinHeaderSize = min
+		options.MaxHeaderSize = max
+	}
+}
+
+// WithLowResDimensionLimit sets the largest accepted thumbnail width & height
+func WithLowResDimensionLimit(pixels int) ReadOption {
+	return func(options *ReaderOptions) {
+		options.LowResDimensionLimit = pixels
+	}
+}
+
+// WithMemoryBudget sets the most bytes a read may hold in memory
+func WithMemoryBudget(bytes int64) ReadOption {
+	return func(options *ReaderOptions) {
+		options.MemoryBudget = bytes
+	}
+}
+
+// WithLenient salvages what it can of damaged files, reporting problems as warnings
+func WithLenient() ReadOption {
+	return func(options *ReaderOptions) {
+		options.Lenient = true
+	}
+}
+
+// newReaderOptions applies read options over the defaults
+func newReaderOptions(options []ReadOption) *ReaderOptions {
+	readerOptions := &ReaderOptions{
+		MaxMipLevel:          -1,
+		DimensionLimit:       defaultDimensionLimit,
+		FrameLimit:           defaultFrameLimit,
+		MinHeaderSize:        defaultMinHeaderSize,
+		MaxHeaderSize:        defaultMaxHeaderSize,
+		LowResDimensionLimit: defaultLowResDimensionLimit,
+	}
+	for _, option := range options {
+		option(readerOptions)
+	}
+
+	return readerOptions
+}
+
+// mipmapCount returns how many mipmaps to load, smallest first, of a texture
+// with the given mipmap sizes
+func (options *ReaderOptions) mipmapCount(sizes [][3]int) int {
+	count := len(sizes)
+	if options.MaxMipLevel >= 0 && options.MaxMipLevel+1 < count {
+		count = options.MaxMipLevel + 1
+	}
+	if options.MaxDimension > 0 {
+		for count > 1 && (sizes[count-1][0] > options.MaxDimension || sizes[count-1][1] > options.MaxDimension) {
+			count--
+		}
+	}
+	if count < 1 && len(sizes) > 0 {
+		count = 1
+	}
+
+	return count
+}
+
+// checkMemoryBudget ensures holding a number of bytes in total stays within the memory budget
+func (options *ReaderOptions) checkMemoryBudget(total int64) error {
+	if options.MemoryBudget > 0 && total > options.MemoryBudget {
+		return fmt.Errorf("%w: %d bytes needed, budget is %d", ErrorMemoryBudgetExceeded, total, options.MemoryBudget)
+	}
+
+	return nil
+}
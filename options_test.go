@@ -0,0 +1,216 @@
+package vtf
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadFromStream_MipmapLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []ReadOption
+		expected int
+	}{
+		{"no limit", nil, 4},
+		{"max mip level", []ReadOption{WithMaxMipLevel(1)}, 2},
+		{"max mip level beyond count", []ReadOption{WithMaxMipLevel(10)}, 4},
+		{"max dimension", []ReadOption{WithMaxDimension(4)}, 3},
+		{"zero max dimension is unlimited", []ReadOption{WithMaxDimension(0)}, 4},
+		{"smallest mipmap always loaded", []ReadOption{WithMaxDimension(1), WithMaxMipLevel(0)}, 1},
+		{"both limits", []ReadOption{WithMaxDimension(4), WithMaxMipLevel(1)}, 2},
+	}
+
+	for _, version := range []uint32{2, 5} {
+		v := newTestVtf(version, 2)
+		var buf bytes.Buffer
+		if err := WriteToStream(&buf, v); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				read, err := ReadFromStream(bytes.NewReader(buf.Bytes()), tt.options...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if read.LoadedMipmapCount() != tt.expected {
+					t.Errorf("7.%d: expected %d mipmaps, got %d", version, tt.expected, read.LoadedMipmapCount())
+				}
+				if !reflect.DeepEqual(read.HighResImageData(), v.HighResImageData()[:tt.expected]) {
+					t.Errorf("7.%d: loaded mipmaps differ from the written texture", version)
+				}
+				if !bytes.Equal(read.Image(), v.HighResImageData()[tt.expected-1][0][0][0]) {
+					t.Errorf("7.%d: expected Image to be the largest loaded mipmap", version)
+				}
+			})
+		}
+	}
+}
+
+func TestReadFromReaderAt_MipmapLimits(t *testing.T) {
+	v := newTestVtf(5, 1)
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, v); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()), WithMaxDimension(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.LoadedMipmapCount() != 2 {
+		t.Errorf("expected 2 mipmaps, got %d", read.LoadedMipmapCount())
+	}
+	if data, err := read.SliceData(1, 0, 0, 0); err != nil || !bytes.Equal(data, v.Slice(1, 0, 0, 0)) {
+		t.Errorf("expected mipmap 1 to be loaded, got %v", err)
+	}
+	if _, err := read.SliceData(2, 0, 0, 0); err == nil {
+		t.Error("expected mipmap 2 to be skipped")
+	}
+	if _, err := read.DecodeMipmap(3, 0); err == nil {
+		t.Error("expected mipmap 3 to be skipped")
+	}
+}
+
+func TestRead_MipmapLimitsSkipLargestMipmap(t *testing.T) {
+	v := newTestVtf(5, 2)
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, v); err != nil {
+		t.Fatal(err)
+	}
+	lazy, err := ReadFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	largest := lazy.layout.mipmapOffsets[len(lazy.layout.mipmapOffsets)-1]
+	expected := v.HighResImageData()[:2]
+
+	stream := &countingReader{stream: bytes.NewReader(buf.Bytes())}
+	read, err := ReadFromStream(stream, WithMaxMipLevel(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stream.read > largest {
+		t.Errorf("stream: expected to stop before the largest mipmap at %d, read %d bytes", largest, stream.read)
+	}
+	if !reflect.DeepEqual(read.HighResImageData(), expected) {
+		t.Error("stream: loaded mipmaps differ from the written texture")
+	}
+
+	source := &countingReaderAt{source: bytes.NewReader(buf.Bytes())}
+	reader := &Reader{options: newReaderOptions([]ReadOption{WithMaxMipLevel(1)})}
+	read, err = reader.readFrom(source, int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source.furthest > largest {
+		t.Errorf("file: expected to stop before the largest mipmap at %d, read up to %d", largest, source.furthest)
+	}
+	if !reflect.DeepEqual(read.HighResImageData(), expected) {
+		t.Error("file: loaded mipmaps differ from the written texture")
+	}
+}
+
+func TestReadFromFile_MipmapLimits(t *testing.T) {
+	v := newTestVtf(2, 2)
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, v); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "test.vtf")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadFromFile(path, WithMaxDimension(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read.HighResImageData(), v.HighResImageData()[:3]) {
+		t.Error("loaded mipmaps differ from the written texture")
+	}
+	streamed, err := ReadFromStream(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.Header() != streamed.Header() || !bytes.Equal(read.LowResImageData(), streamed.LowResImageData()) {
+		t.Error("header or thumbnail differs from ReadFromStream")
+	}
+}
+
+func TestReadFromStream_Limits(t *testing.T) {
+	data, err := os.ReadFile("samples/read/test.vtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		options  []ReadOption
+		expected error
+	}{
+		{"defaults", nil, nil},
+		{"dimension limit", []ReadOption{WithDimensionLimit(256)}, ErrorInvalidDimensions},
+		{"frame limit", []ReadOption{WithFrameLimit(0)}, ErrorInvalidDimensions},
+		{"header size limits", []ReadOption{WithHeaderSizeLimits(96, 1024)}, ErrorInvalidHeaderSize},
+		{"low res dimension limit", []ReadOption{WithLowResDimensionLimit(8)}, ErrorInvalidDimensions},
+		{"memory budget", []ReadOption{WithMemoryBudget(int64(len(data)) - 1)}, ErrorMemoryBudgetExceeded},
+		{"memory budget with thumbnail", []ReadOption{WithMemoryBudget(int64(len(data)))}, ErrorMemoryBudgetExceeded},
+		{"large memory budget", []ReadOption{WithMemoryBudget(int64(len(data)) * 2)}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadFromStream(bytes.NewReader(data), tt.options...)
+			if tt.expected == nil && err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+			if tt.expected != nil && !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestReadFromStream_LooserLimits(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, newTestVtf(2, 1)); err != nil {
+		t.Fatal(err)
+	}
+	// Widen the 4x4 thumbnail to 32x4, beyond the default limit
+	data := buf.Bytes()
+	headerSize := int(data[headerOffset("HeaderSize")])
+	data[headerOffset("LowResImageWidth")] = 32
+	data = append(data[:headerSize:headerSize], append(make([]byte, 7*8), data[headerSize:]...)...)
+
+	if _, err := ReadFromStream(bytes.NewReader(data)); !errors.Is(err, ErrorInvalidDimensions) {
+		t.Errorf("expected %v, got %v", ErrorInvalidDimensions, err)
+	}
+	v, err := ReadFromStream(bytes.NewReader(data), WithLowResDimensionLimit(32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.LowResImageData()) != 8*8 {
+		t.Errorf("expected a 64 byte thumbnail, got %d", len(v.LowResImageData()))
+	}
+}
+
+func TestReadFromReaderAt_MemoryBudget(t *testing.T) {
+	v := newTestVtf(5, 1)
+	v.SetResource(Resource{Tag: ResourceTagKeyValues, Data: make([]byte, 1024)})
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, v); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ReadFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()), WithMemoryBudget(512))
+	if !errors.Is(err, ErrorMemoryBudgetExceeded) {
+		t.Errorf("expected %v, got %v", ErrorMemoryBudgetExceeded, err)
+	}
+	if _, err := ReadFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()), WithMemoryBudget(2048)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
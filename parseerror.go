@@ -0,0 +1,151 @@
+package vtf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity is how serious a problem found while reading a vtf is
+type Severity int
+
+const (
+	// SeverityError problems prevent a vtf from being read
+	SeverityError Severity = iota
+	// SeverityWarning problems leave a vtf readable, but some of its data may be missing
+	SeverityWarning
+)
+
+// String returns the name of a Severity
+func (severity Severity) String() string {
+	if severity == SeverityWarning {
+		return "warning"
+	}
+
+	return "error"
+}
+
+// Names of the parts of a file that are not Header fields or surfaces
+const (
+	// FieldHeader is the Header as a whole, such as when the file is shorter than a Header
+	FieldHeader = "Header"
+	// FieldLowResImageData is the low resolution thumbnail
+	FieldLowResImageData = "LowResImageData"
+	// FieldHighResImageData is the high resolution image data as a whole
+	FieldHighResImageData = "HighResImageData"
+)
+
+// headerFieldOffsets are the offsets of Header fields from the start of the file
+var headerFieldOffsets = map[string]int64{
+	"Signature":          0,
+	"Version":            4,
+	"HeaderSize":         12,
+	"Width":              16,
+	"Height":             18,
+	"Flags":              20,
+	"Frames":             24,
+	"FirstFrame":         26,
+	"Reflectivity":       32,
+	"BumpmapScale":       48,
+	"HighResImageFormat": 52,
+	"MipmapCount":        56,
+	"LowResImageFormat":  57,
+	"LowResImageWidth":   61,
+	"LowResImageHeight":  62,
+	"Depth":              63,
+	"NumResource":        68,
+}
+
+// ParseError describes where in a file a vtf could not be read.
+// Err wraps one of the Error* values, so errors.Is works on a ParseError.
+type ParseError struct {
+	// Err is the problem that was found
+	Err error
+	// Offset of the problem from the start of the file, or -1 if unknown
+	Offset int64
+	// Field is the Header field or resource at fault, such as "Width" or "Resource KVD",
+	// or one of FieldHeader, FieldLowResImageData & FieldHighResImageData.
+	// Empty when a single surface is at fault
+	Field string
+	// Mip, Frame, Face & Slice locate the surface at fault.
+	// All are -1 when the problem is not with a single surface
+	Mip   int
+	Frame int
+	Face  int
+	Slice int
+	// Severity is how serious the problem is
+	Severity Severity
+}
+
+// Error describes the problem & where it was found
+func (err *ParseError) Error() string {
+	var builder strings.Builder
+	builder.WriteString("vtf: ")
+	if err.Severity != SeverityError {
+		builder.WriteString(err.Severity.String())
+		builder.WriteString(": ")
+	}
+	if err.IsSurface() {
+		fmt.Fprintf(&builder, "mipmap %d, frame %d, face %d, slice %d", err.Mip, err.Frame, err.Face, err.Slice)
+	} else {
+		builder.WriteString(err.Field)
+	}
+	if err.Offset >= 0 {
+		fmt.Fprintf(&builder, " at offset %d", err.Offset)
+	}
+	builder.WriteString(": ")
+	builder.WriteString(err.Err.Error())
+
+	return builder.String()
+}
+
+// Unwrap returns the underlying error
+func (err *ParseError) Unwrap() error {
+	return err.Err
+}
+
+// IsSurface returns whether a single surface is at fault
+func (err *ParseError) IsSurface() bool {
+	return err.Mip >= 0
+}
+
+// fieldError creates a ParseError for a Header field, resource or other part of a file
+func fieldError(field string, offset int64, err error) *ParseError {
+	return &ParseError{
+		Err:    err,
+		Offset: offset,
+		Field:  field,
+		Mip:    -1,
+		Frame:  -1,
+		Face:   -1,
+		Slice:  -1,
+	}
+}
+
+// headerError creates a ParseError for a Header field
+func headerError(field string, err error) *ParseError {
+	return fieldError(field, headerFieldOffsets[field], err)
+}
+
+// surfaceError creates a ParseError for a single surface
+func surfaceError(mipmap int, frame int, face int, slice int, offset int64, err error) *ParseError {
+	return &ParseError{
+		Err:    err,
+		Offset: offset,
+		Mip:    mipmap,
+		Frame:  frame,
+		Face:   face,
+		Slice:  slice,
+	}
+}
+
+// resourceField returns the ParseError field name of a resource
+func resourceField(tag [3]byte) string {
+	switch tag {
+	case ResourceTagLowResImage:
+		return FieldLowResImageData
+	case ResourceTagHighResImage:
+		return FieldHighResImageData
+	}
+
+	return fmt.Sprintf("Resource %q", string(tag[:]))
+}
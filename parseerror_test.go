@@ -0,0 +1,134 @@
+package vtf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// headerOffset returns the offset of a Header field from the start of the file
+func headerOffset(field string) int {
+	offset, ok := headerFieldOffsets[field]
+	if !ok {
+		panic("unknown header field " + field)
+	}
+
+	return int(offset)
+}
+
+func TestHeaderFieldOffsets(t *testing.T) {
+	// Walk the packed binary layout of Header, including its embedded structs
+	offsets := map[string]int64{}
+	var walk func(typ reflect.Type, offset int64) int64
+	walk = func(typ reflect.Type, offset int64) int64 {
+		for idx := 0; idx < typ.NumField(); idx++ {
+			field := typ.Field(idx)
+			if field.Anonymous {
+				offset = walk(field.Type, offset)
+				continue
+			}
+			offsets[field.Name] = offset
+			offset += int64(binary.Size(reflect.Zero(field.Type).Interface()))
+		}
+		return offset
+	}
+	walk(reflect.TypeOf(Header{}), 0)
+
+	for field, offset := range headerFieldOffsets {
+		if actual, ok := offsets[field]; !ok || actual != offset {
+			t.Errorf("%s: expected offset %d, got %d", field, actual, offset)
+		}
+	}
+}
+
+func TestParseError_HeaderField(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, newTestVtf(5, 1)); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	widthOffset := headerOffset("Width")
+	data[widthOffset], data[widthOffset+1] = 0, 0
+
+	_, err := ReadFromStream(bytes.NewReader(data))
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a ParseError, got %v", err)
+	}
+	if !errors.Is(err, ErrorInvalidDimensions) {
+		t.Errorf("expected %v, got %v", ErrorInvalidDimensions, err)
+	}
+	if parseErr.Field != "Width" || parseErr.Offset != int64(widthOffset) || parseErr.IsSurface() || parseErr.Severity != SeverityError {
+		t.Errorf("unexpected error location: %+v", parseErr)
+	}
+	if !strings.HasPrefix(err.Error(), fmt.Sprintf("vtf: Width at offset %d: ", widthOffset)) {
+		t.Errorf("unexpected message: %s", err)
+	}
+}
+
+func TestParseError_Resource(t *testing.T) {
+	v := newTestVtf(5, 1)
+	v.SetResource(Resource{Tag: ResourceTagKeyValues, Data: []byte("key value")})
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, v); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	written, err := ReadFromStream(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset := int64(written.Resource(ResourceTagKeyValues).Offset)
+	data[offset], data[offset+1], data[offset+2], data[offset+3] = 0xff, 0xff, 0xff, 0xff
+
+	_, err = ReadFromStream(bytes.NewReader(data))
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || !errors.Is(err, ErrorInvalidResource) {
+		t.Fatalf("expected a ParseError wrapping %v, got %v", ErrorInvalidResource, err)
+	}
+	if parseErr.Field != `Resource "KVD"` || parseErr.Offset != offset {
+		t.Errorf("unexpected error location: %+v", parseErr)
+	}
+}
+
+func TestParseError_TruncatedSurface(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, newTestVtf(5, 3)); err != nil {
+		t.Fatal(err)
+	}
+	lazy, err := ReadFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Cut the file part way through frame 1 of mipmap 2
+	surfaceStart := lazy.layout.mipmapOffsets[2] + int64(lazy.layout.surfaceSizes[2])
+	truncated := buf.Bytes()[:surfaceStart+5]
+
+	check := func(name string, err error) {
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || !errors.Is(err, ErrorMipmapSizeMismatch) {
+			t.Fatalf("%s: expected a ParseError wrapping %v, got %v", name, ErrorMipmapSizeMismatch, err)
+		}
+		if parseErr.Mip != 2 || parseErr.Frame != 1 || parseErr.Face != 0 || parseErr.Slice != 0 || parseErr.Offset != surfaceStart {
+			t.Errorf("%s: unexpected error location: %+v", name, parseErr)
+		}
+		if !strings.HasPrefix(err.Error(), "vtf: mipmap 2, frame 1, face 0, slice 0 at offset ") {
+			t.Errorf("%s: unexpected message: %s", name, err)
+		}
+	}
+
+	_, err = ReadFromStream(bytes.NewReader(truncated))
+	check("ReadFromStream", err)
+
+	streamReader, err := NewStreamReader(bytes.NewReader(truncated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("StreamReader", streamReader.ReadSurfaces(func(mipmap int, frame int, face int, slice int, data []byte) error {
+		return nil
+	}))
+}
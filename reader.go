@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 
 	"github.com/galaco/vtf/format"
 	"github.com/galaco/vtf/internal"
@@ -13,12 +14,21 @@ import (
 
 const (
 	vtfSignature = "VTF\x00"
+	// headerSize73 is the size of the 7.3+ header before the resource directory
+	headerSize73 = 80
+	// resourceEntrySize is the size of a single resource directory entry
+	resourceEntrySize = 8
+	// headerPrefixSize is the size of the signature, version & header size that
+	// start every header
+	headerPrefixSize = 16
 )
 
 var (
 	// ErrorVtfSignatureMismatch occurs when a stream does not start with the VTF magic signature
 	ErrorVtfSignatureMismatch = errors.New("header signature does not match: VTF\x00")
 	// ErrorTextureDepthNotSupported occurs when attempting to parse a stream with depth > 1
+	//
+	// Deprecated: volume textures are supported, so this is no longer returned
 	ErrorTextureDepthNotSupported = errors.New("only vtf textures with depth 1 are supported")
 	// ErrorMipmapSizeMismatch occurs when filesize does not match calculated mipmap size
 	ErrorMipmapSizeMismatch = errors.New("expected data size is smaller than actual")
@@ -34,244 +44,528 @@ var (
 
 // Reader reads from a vtf stream
 type Reader struct {
-	stream io.Reader
+	stream  io.Reader
+	options *ReaderOptions
+	// held is the number of bytes held in memory by the current read
+	held int64
+	// warnings are the problems a lenient read has recovered from
+	warnings []*ParseError
+}
+
+// readerOptions returns the options of this reader, or the defaults if unset
+func (reader *Reader) readerOptions() *ReaderOptions {
+	if reader.options == nil {
+		return newReaderOptions(nil)
+	}
+
+	return reader.options
+}
+
+// hold records that a read holds more bytes in memory, failing if that
+// exceeds the memory budget
+func (reader *Reader) hold(size int64) error {
+	reader.held += size
+	return reader.readerOptions().checkMemoryBudget(reader.held)
+}
+
+// recover records a problem as a warning when reading leniently, so reading can continue.
+// Otherwise the problem is returned, and reading must stop
+func (reader *Reader) recover(err *ParseError) error {
+	if !reader.readerOptions().Lenient {
+		return err
+	}
+	err.Severity = SeverityWarning
+	reader.warnings = append(reader.warnings, err)
+
+	return nil
 }
 
 // ReadHeader reads the header of a texture only.
+// Only HeaderSize bytes are consumed from the stream; the header and the 7.3+
+// resource directory. The header is validated, but resource data is not read.
 func (reader *Reader) ReadHeader() (*Header, error) {
-	data, err := io.ReadAll(reader.stream)
+	header, _, err := reader.readHeaderAndDirectory()
+	return header, err
+}
+
+// readHeaderAndDirectory reads & validates the header and the raw 7.3+ resource
+// directory, consuming exactly HeaderSize bytes of the stream
+func (reader *Reader) readHeaderAndDirectory() (*Header, []byte, error) {
+	// Signature, version & header size
+	prefix := make([]byte, headerPrefixSize)
+	if n, err := io.ReadFull(reader.stream, prefix); err != nil {
+		return nil, nil, fieldError(FieldHeader, int64(n), err)
+	}
+	if string(prefix[:4]) != vtfSignature {
+		return nil, nil, headerError("Signature", ErrorVtfSignatureMismatch)
+	}
+	headerSize := binary.LittleEndian.Uint32(prefix[12:16])
+	options := reader.readerOptions()
+	if int64(headerSize) < int64(options.MinHeaderSize) || int64(headerSize) > int64(options.MaxHeaderSize) || headerSize < headerPrefixSize {
+		return nil, nil, headerError("HeaderSize", fmt.Errorf("%w: %d bytes (expected %d-%d)", ErrorInvalidHeaderSize, headerSize, options.MinHeaderSize, options.MaxHeaderSize))
+	}
+
+	// Older headers are shorter than Header; the fields they lack are zeroed
+	buffer := make([]byte, headerSize)
+	if size := binary.Size(Header{}); len(buffer) < size {
+		buffer = make([]byte, size)
+	}
+	copy(buffer, prefix)
+	if n, err := io.ReadFull(reader.stream, buffer[headerPrefixSize:headerSize]); err != nil {
+		return nil, nil, fieldError(FieldHeader, int64(headerPrefixSize+n), err)
+	}
+
+	header, err := reader.parseHeader(buffer)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if err := reader.validateHeader(header, int64(headerSize)); err != nil {
+		return nil, nil, err
+	}
+	directory, err := reader.readResourceDirectory(header, bytes.NewReader(buffer), int64(headerSize))
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Header
-	return reader.parseHeader(data)
+	return header, directory, nil
 }
 
 // Read parses vtf image from stream into a usable structure
 // The only error to expect would be if mipmap data size overflows the total file size; normally
 // due to tampered Header data.
+// Problems with the file are returned as a *ParseError, locating the problem within the file.
+// When only some mipmaps are loaded from a 7.3+ file, the stream is read no further than they
+// are, unless other resources are stored after them.
 func (reader *Reader) Read() (*Vtf, error) {
-	data, err := io.ReadAll(reader.stream)
+	// Read at most 1 byte more than the memory budget, to detect exceeding it
+	stream := reader.stream
+	if budget := reader.readerOptions().MemoryBudget; budget > 0 {
+		stream = io.LimitReader(stream, budget+1)
+	}
+
+	// Everything read while looking for the end of the loaded mipmaps is kept for parsing.
+	// Problems with the header are reported once the whole file has been read
+	var buffer bytes.Buffer
+	end := int64(math.MaxInt64)
+	peek := &Reader{
+		stream:  io.TeeReader(stream, &buffer),
+		options: reader.options,
+	}
+	if header, directory, err := peek.readHeaderAndDirectory(); err == nil {
+		if loadedEnd, ok := reader.loadedEnd(header, directory); ok {
+			end = loadedEnd
+		}
+	}
+	if _, err := io.CopyN(&buffer, stream, end-int64(buffer.Len())); err != nil && err != io.EOF {
+		return nil, err
+	}
+	data := buffer.Bytes()
+	if err := reader.hold(int64(len(data))); err != nil {
+		return nil, err
+	}
+
+	vtf, layout, err := reader.readStructure(bytes.NewReader(data), int64(len(data)))
 	if err != nil {
 		return nil, err
 	}
 
-	// Header
-	header, err := reader.parseHeader(data)
+	// Mipmaps
+	// When only some are loaded, copy them so the rest of the file can be freed
+	mipmapData := data[layout.start:layout.end]
+	if len(layout.sizes) < int(vtf.header.MipmapCount) && layout.end < int64(len(data)) {
+		if err := reader.hold(int64(len(mipmapData))); err != nil {
+			return nil, err
+		}
+		mipmapData = append([]byte(nil), mipmapData...)
+	}
+	vtf.highResolutionImageData = layout.splitMipmaps(mipmapData)
+
+	return vtf, nil
+}
+
+// loadedEnd returns the offset of the end of the mipmaps that should be loaded from a 7.3+
+// file, when only some are and every other resource is stored before them.
+// Returns false if the whole file must be read
+func (reader *Reader) loadedEnd(header *Header, directory []byte) (int64, bool) {
+	resources := parseResourceDirectory(directory)
+	highRes := resourceByTag(resources, ResourceTagHighResImage)
+	if highRes == nil {
+		return 0, false
+	}
+	for _, resource := range resources {
+		if resource.Tag != ResourceTagHighResImage && resource.Flags&ResourceFlagNoDataChunk == 0 && resource.Offset >= highRes.Offset {
+			return 0, false
+		}
+	}
+
+	layout := computeImageLayout(header)
+	count := reader.readerOptions().mipmapCount(layout.sizes)
+	if count == len(layout.sizes) {
+		return 0, false
+	}
+	layout.moveTo(int64(highRes.Offset))
+	layout.truncate(count)
+
+	return layout.end, true
+}
+
+// readFrom reads a vtf and the mipmaps that should be loaded from a random access
+// source, reading no further into it than those mipmaps
+func (reader *Reader) readFrom(source io.ReaderAt, size int64) (*Vtf, error) {
+	vtf, layout, err := reader.readStructure(source, size)
 	if err != nil {
 		return nil, err
 	}
 
-	// Validate header to prevent DoS attacks via malicious files
-	if err := reader.validateHeader(header, len(data)); err != nil {
+	if err := reader.hold(layout.end - layout.start); err != nil {
 		return nil, err
 	}
+	mipmaps, err := layout.readMipmaps(source)
+	if err != nil {
+		return nil, err
+	}
+	vtf.highResolutionImageData = mipmaps
+
+	return vtf, nil
+}
+
+// readStructure reads everything but the high resolution image data from a random
+// access source; the Header, resources and low resolution thumbnail. The returned layout
+// locates the high resolution image data within the source.
+func (reader *Reader) readStructure(source io.ReaderAt, size int64) (*Vtf, *imageLayout, error) {
+	if size < 0 {
+		return nil, nil, fieldError(FieldHeader, -1, fmt.Errorf("%w: file size %d is negative", ErrorInvalidHeaderSize, size))
+	}
+
+	// Header. Older headers are shorter than Header, and small textures may end before
+	// Header would; bytes beyond the header or the file are zeroed, as when streaming
+	headerBytes := make([]byte, binary.Size(Header{}))
+	available := int64(len(headerBytes))
+	if size < available {
+		available = size
+	}
+	if available < headerPrefixSize {
+		return nil, nil, fieldError(FieldHeader, available, io.ErrUnexpectedEOF)
+	}
+	if err := readAt(source, headerBytes[:available], 0); err != nil {
+		return nil, nil, fieldError(FieldHeader, 0, err)
+	}
+	if headerSize := int64(binary.LittleEndian.Uint32(headerBytes[12:16])); headerSize >= headerPrefixSize && headerSize < available {
+		for i := headerSize; i < available; i++ {
+			headerBytes[i] = 0
+		}
+	}
+	header, err := reader.parseHeader(headerBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Validate header to prevent DoS attacks via malicious files
+	if err := reader.validateHeader(header, size); err != nil {
+		return nil, nil, err
+	}
 
 	// Resources - in vtf 7.3+ only
-	resourceData, err := reader.parseOtherResourceData(header, data)
+	resourceData, err := reader.parseOtherResourceData(header, source, size)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Low resolution preview texture
-	lowResImage, err := reader.readLowResolutionMipmap(header, data)
+	lowResImage, err := reader.readLowResolutionMipmap(header, source, size, resourceData)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// Mipmaps
-	highResImage, err := reader.readMipmaps(header, data)
+	// Mipmap locations, limited to those that should be loaded
+	options := reader.readerOptions()
+	layout, err := newImageLayout(header, resourceData, size, options)
+	if options.Lenient && (err != nil || overlapsLowResImage(header, resourceData, layout)) {
+		// Keep the mipmaps that fit
+		var warning *ParseError
+		layout, warning = salvageImageLayout(header, resourceData, size)
+		layout.truncate(options.mipmapCount(layout.sizes))
+		err = reader.recover(warning)
+	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	return &Vtf{
-		header:                  *header,
-		resources:               resourceData,
-		lowResolutionImageData:  lowResImage,
-		highResolutionImageData: highResImage,
-	}, nil
+		header:                 *header,
+		resources:              resourceData,
+		lowResolutionImageData: lowResImage,
+		warnings:               reader.warnings,
+	}, layout, nil
 }
 
-// parseHeader reads vtf Header.
+// parseHeader reads vtf Header. Callers zero pad buffer to the size of Header,
+// as older headers and small textures may be shorter
 func (reader *Reader) parseHeader(buffer []byte) (*Header, error) {
-	// We know Header is 96 bytes maximum
-	// Note it *may* not be someday...
-	headerBytes := make([]byte, 96)
-
-	// Read bytes equal to Header size
-	byteReader := bytes.NewReader(buffer)
-	sectionReader := io.NewSectionReader(byteReader, 0, int64(len(headerBytes)))
-	_, err := sectionReader.Read(headerBytes)
-	if err != nil {
-		return nil, err
+	header := Header{}
+	if len(buffer) < binary.Size(header) {
+		return nil, fieldError(FieldHeader, int64(len(buffer)), io.ErrUnexpectedEOF)
 	}
 
 	// Set Header data to read bytes
-	header := Header{}
-	err = binary.Read(bytes.NewBuffer(headerBytes[:]), binary.LittleEndian, &header)
+	err := binary.Read(bytes.NewReader(buffer), binary.LittleEndian, &header)
 	if err != nil {
 		return nil, err
 	}
 	if string(header.Signature[:4]) != vtfSignature {
-		return nil, ErrorVtfSignatureMismatch
+		return nil, headerError("Signature", ErrorVtfSignatureMismatch)
 	}
 
 	return &header, nil
 }
 
 // validateHeader performs security validation on header fields to prevent DoS attacks
-func (reader *Reader) validateHeader(header *Header, fileSize int) error {
-	// Validate version - only support 7.1 to 7.5
+// Limits are set by the reader's options.
+func (reader *Reader) validateHeader(header *Header, fileSize int64) error {
+	options := reader.readerOptions()
+
+	// Validate version - only support 7.0 to 7.5
 	majorVersion := header.Version[0]
 	minorVersion := header.Version[1]
 	version := majorVersion*10 + minorVersion
 	if majorVersion != 7 || version < 70 || version > 75 {
-		return fmt.Errorf("%w: %d.%d (only 7.0-7.5 supported)", ErrorUnsupportedVersion, majorVersion, minorVersion)
+		return headerError("Version", fmt.Errorf("%w: %d.%d (only 7.0-7.5 supported)", ErrorUnsupportedVersion, majorVersion, minorVersion))
 	}
 
 	// Validate dimensions
 	if header.Width == 0 || header.Height == 0 {
-		return fmt.Errorf("%w: width=%d, height=%d (cannot be zero)", ErrorInvalidDimensions, header.Width, header.Height)
+		return headerError(dimensionField(header.Width == 0), fmt.Errorf("%w: width=%d, height=%d (cannot be zero)", ErrorInvalidDimensions, header.Width, header.Height))
 	}
 
-	// Prevent excessive memory allocation - Source Engine typically uses max 4096x4096
-	const maxDimension = 16384 // Allow some headroom beyond typical max
-	if header.Width > maxDimension || header.Height > maxDimension {
-		return fmt.Errorf("%w: width=%d, height=%d (max %d)", ErrorInvalidDimensions, header.Width, header.Height, maxDimension)
+	// Prevent excessive memory allocation
+	maxDimension := options.DimensionLimit
+	if int(header.Width) > maxDimension || int(header.Height) > maxDimension {
+		return headerError(dimensionField(int(header.Width) > maxDimension), fmt.Errorf("%w: width=%d, height=%d (max %d)", ErrorInvalidDimensions, header.Width, header.Height, maxDimension))
 	}
 
-	// Validate mipmap count - should not exceed log2(max(width, height)) + 1
-	maxDim := header.Width
-	if header.Height > maxDim {
-		maxDim = header.Height
+	// Surfaces can only be located if the size of the format is known
+	if !format.Format(header.HighResImageFormat).IsKnown() {
+		return headerError("HighResImageFormat", fmt.Errorf("%w: %d", ErrorUnsupportedFormat, header.HighResImageFormat))
 	}
-	var maxMipmaps uint8 = 0
-	for d := maxDim; d > 0; d >>= 1 {
-		maxMipmaps++
+
+	// Validate mipmap count - should not exceed log2(max(width, height, depth)) + 1
+	depth := 1
+	if version >= 72 {
+		depth = int(header.Depth)
 	}
+	maxMipmaps := uint8(internal.MipmapCount(int(header.Width), int(header.Height), depth))
 	if header.MipmapCount == 0 || header.MipmapCount > maxMipmaps {
-		return fmt.Errorf("%w: count=%d, expected 1-%d for %dx%d texture", ErrorInvalidMipmapCount, header.MipmapCount, maxMipmaps, header.Width, header.Height)
+		return headerError("MipmapCount", fmt.Errorf("%w: count=%d, expected 1-%d for %dx%d texture", ErrorInvalidMipmapCount, header.MipmapCount, maxMipmaps, header.Width, header.Height))
 	}
 
 	// Validate header size
-	if header.HeaderSize < 64 || header.HeaderSize > 1024 {
-		return fmt.Errorf("%w: %d bytes (expected 64-1024)", ErrorInvalidHeaderSize, header.HeaderSize)
+	if int64(header.HeaderSize) < int64(options.MinHeaderSize) || int64(header.HeaderSize) > int64(options.MaxHeaderSize) {
+		return headerError("HeaderSize", fmt.Errorf("%w: %d bytes (expected %d-%d)", ErrorInvalidHeaderSize, header.HeaderSize, options.MinHeaderSize, options.MaxHeaderSize))
 	}
 
 	// Validate header size doesn't exceed file size
-	if int(header.HeaderSize) > fileSize {
-		return fmt.Errorf("%w: header size %d exceeds file size %d", ErrorInvalidHeaderSize, header.HeaderSize, fileSize)
+	if int64(header.HeaderSize) > fileSize {
+		return headerError("HeaderSize", fmt.Errorf("%w: header size %d exceeds file size %d", ErrorInvalidHeaderSize, header.HeaderSize, fileSize))
 	}
 
 	// Validate frame count is reasonable
-	if header.Frames == 0 || header.Frames > 1024 {
-		return fmt.Errorf("%w: frame count %d is invalid (expected 1-1024)", ErrorInvalidDimensions, header.Frames)
+	if header.Frames == 0 || int(header.Frames) > options.FrameLimit {
+		return headerError("Frames", fmt.Errorf("%w: frame count %d is invalid (expected 1-%d)", ErrorInvalidDimensions, header.Frames, options.FrameLimit))
 	}
 
 	// Validate depth (if present in v7.2+)
-	if version >= 72 && header.Depth > 1 {
-		return ErrorTextureDepthNotSupported
+	if version >= 72 && int(header.Depth) > maxDimension {
+		return headerError("Depth", fmt.Errorf("%w: depth=%d (max %d)", ErrorInvalidDimensions, header.Depth, maxDimension))
 	}
 
 	// Validate low-res dimensions
-	if header.LowResImageWidth > 16 || header.LowResImageHeight > 16 {
-		return fmt.Errorf("%w: low-res dimensions %dx%d exceed expected maximum 16x16", ErrorInvalidDimensions, header.LowResImageWidth, header.LowResImageHeight)
+	maxLowResDimension := options.LowResDimensionLimit
+	if int(header.LowResImageWidth) > maxLowResDimension || int(header.LowResImageHeight) > maxLowResDimension {
+		field := "LowResImageHeight"
+		if int(header.LowResImageWidth) > maxLowResDimension {
+			field = "LowResImageWidth"
+		}
+		return headerError(field, fmt.Errorf("%w: low-res dimensions %dx%d exceed expected maximum %dx%d", ErrorInvalidDimensions, header.LowResImageWidth, header.LowResImageHeight, maxLowResDimension, maxLowResDimension))
 	}
 
 	return nil
 }
 
-// parseOtherResourceData reads resource data for 7.3+ images
-func (reader *Reader) parseOtherResourceData(header *Header, buffer []byte) ([]byte, error) {
-	// validate Header version
-	if (header.Version[0]*10+header.Version[1] < 73) || header.NumResource == 0 {
+// dimensionField returns the name of the Width field if it is at fault, otherwise Height
+func dimensionField(width bool) string {
+	if width {
+		return "Width"
+	}
+
+	return "Height"
+}
+
+// readResourceDirectory reads the raw 7.3+ resource directory, which immediately
+// follows the 80 byte header. Header fields that don't exist in older versions
+// are zeroed; older versions have no directory.
+func (reader *Reader) readResourceDirectory(header *Header, source io.ReaderAt, size int64) ([]byte, error) {
+	version := header.Version[0]*10 + header.Version[1]
+
+	// Fields that don't exist in older versions overlap other data
+	if version < 72 {
 		header.Depth = 0
+	}
+	if version < 73 || header.NumResource == 0 {
 		header.NumResource = 0
-		return []byte{}, nil
+		return nil, nil
+	}
+
+	directoryEnd := int64(headerSize73) + int64(header.NumResource)*resourceEntrySize
+	if directoryEnd > int64(header.HeaderSize) || directoryEnd > size {
+		return nil, headerError("NumResource", fmt.Errorf("%w: %d resource entries exceed header size %d", ErrorInvalidResource, header.NumResource, header.HeaderSize))
+	}
+	directory := make([]byte, directoryEnd-headerSize73)
+	if err := readAt(source, directory, headerSize73); err != nil {
+		return nil, fieldError(FieldHeader, headerSize73, err)
 	}
 
-	return []byte{}, nil
+	return directory, nil
+}
+
+// parseOtherResourceData reads resource data for 7.3+ images
+func (reader *Reader) parseOtherResourceData(header *Header, source io.ReaderAt, size int64) ([]Resource, error) {
+	directory, err := reader.readResourceDirectory(header, source, size)
+	if err != nil || directory == nil {
+		return []Resource{}, err
+	}
+
+	// Lenient reads drop resources that cannot be read
+	resources := parseResourceDirectory(directory)
+	readable := resources[:0]
+	for idx := range resources {
+		resource := resources[idx]
+		err := reader.readResourceData(&resource, int64(headerSize73+idx*resourceEntrySize+4), source, size)
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) && resource.IsImage() && reader.readerOptions().Lenient {
+			// Reading the thumbnail & mipmaps reports which image data is missing
+			err = nil
+		} else if errors.As(err, &parseErr) {
+			err = reader.recover(parseErr)
+			if err == nil {
+				continue
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		readable = append(readable, resource)
+	}
+
+	return readable, nil
+}
+
+// readResourceData reads the data of a single resource.
+// entryOffset is the offset of the resource's value within the resource directory
+func (reader *Reader) readResourceData(resource *Resource, entryOffset int64, source io.ReaderAt, size int64) error {
+	value := resource.Offset
+
+	switch {
+	case resource.Flags&ResourceFlagNoDataChunk != 0:
+		// Value is the data
+	case resource.IsImage():
+		// Image data has no length prefix
+		if int64(value) > size {
+			return fieldError(resourceField(resource.Tag), entryOffset, fmt.Errorf("%w: offset %d exceeds file size %d", ErrorInvalidResource, value, size))
+		}
+	default:
+		// Data is prefixed with its length
+		if int64(value)+4 > size {
+			return fieldError(resourceField(resource.Tag), entryOffset, fmt.Errorf("%w: offset %d exceeds file size %d", ErrorInvalidResource, value, size))
+		}
+		var length [4]byte
+		if err := readAt(source, length[:], int64(value)); err != nil {
+			return fieldError(resourceField(resource.Tag), int64(value), err)
+		}
+		dataSize := binary.LittleEndian.Uint32(length[:])
+		if int64(value)+4+int64(dataSize) > size {
+			return fieldError(resourceField(resource.Tag), int64(value), fmt.Errorf("%w: size %d exceeds file size %d", ErrorInvalidResource, dataSize, size))
+		}
+		if err := reader.hold(int64(dataSize)); err != nil {
+			return err
+		}
+		resource.Data = make([]byte, dataSize)
+		if err := readAt(source, resource.Data, int64(value)+4); err != nil {
+			return fieldError(resourceField(resource.Tag), int64(value)+4, err)
+		}
+	}
+
+	return nil
+}
+
+// parseResourceDirectory decodes every entry of a raw resource directory, without
+// reading any resource data. Entries flagged ResourceFlagNoDataChunk have their
+// value as Data; all others have it as Offset.
+func parseResourceDirectory(directory []byte) []Resource {
+	resources := make([]Resource, len(directory)/resourceEntrySize)
+	for idx := range resources {
+		entry := directory[idx*resourceEntrySize : (idx+1)*resourceEntrySize]
+		resource := Resource{
+			Flags: entry[3],
+		}
+		copy(resource.Tag[:], entry[0:3])
+		if resource.Flags&ResourceFlagNoDataChunk != 0 {
+			resource.Data = append([]byte(nil), entry[4:8]...)
+		} else {
+			resource.Offset = binary.LittleEndian.Uint32(entry[4:8])
+		}
+		resources[idx] = resource
+	}
+
+	return resources
 }
 
 // readLowResolutionMipmap reads the low resolution texture information
 // This is normally what you see previewed in Hammer texture browser.
 // The largest axis should always be 16 wide/tall. The smallest can be any value,
 // but is padded out to divisible by 4 for Dxt1 compressionn reasons
-func (reader *Reader) readLowResolutionMipmap(header *Header, buffer []byte) ([]uint8, error) {
-	bufferSize := internal.ComputeSizeOfMipmapData(
-		int(header.LowResImageWidth),
-		int(header.LowResImageHeight),
-		format.Dxt1)
+// The format is whatever the Header declares; textures without a thumbnail return no data.
+// In 7.3+ the location is given by its resource entry, otherwise it immediately follows the Header.
+func (reader *Reader) readLowResolutionMipmap(header *Header, source io.ReaderAt, size int64, resources []Resource) ([]uint8, error) {
+	if !header.HasLowResImage() {
+		return nil, nil
+	}
 
-	imgBuffer := make([]byte, bufferSize)
-	byteReader := bytes.NewReader(buffer[int(header.HeaderSize) : int(header.HeaderSize)+bufferSize])
-	sectionReader := io.NewSectionReader(byteReader, 0, int64(bufferSize))
-	_, err := sectionReader.Read(imgBuffer)
-	if err != nil {
+	bufferSize := lowResImageDataSize(header)
+
+	bufferOffset := int64(header.HeaderSize)
+	if header.NumResource > 0 {
+		resource := resourceByTag(resources, ResourceTagLowResImage)
+		if resource == nil {
+			return nil, nil
+		}
+		bufferOffset = int64(resource.Offset)
+	}
+
+	// Lenient reads leave out a thumbnail that cannot be read
+	if bufferOffset > size || int64(bufferSize) > size-bufferOffset {
+		return nil, reader.recover(fieldError(FieldLowResImageData, bufferOffset, fmt.Errorf("%w: %d bytes exceed file size %d", ErrorImageDataTooSmall, bufferSize, size)))
+	}
+
+	if err := reader.hold(int64(bufferSize)); err != nil {
 		return nil, err
 	}
+	imgBuffer := make([]byte, bufferSize)
+	if err := readAt(source, imgBuffer, bufferOffset); err != nil {
+		return nil, reader.recover(fieldError(FieldLowResImageData, bufferOffset, err))
+	}
 
 	return imgBuffer, nil
 }
 
-// readMipmaps reads all mipmaps
-// Returned format is a bit odd, but is just a set of flat arrays containing arrays:
-// mipmap[frame[face[slice[RGBA]]]
-func (reader *Reader) readMipmaps(header *Header, buffer []byte) ([][][][][]uint8, error) {
-	if header.Depth > 1 {
-		return [][][][][]uint8{}, ErrorTextureDepthNotSupported
-	}
-
-	depth := header.Depth
-
-	// Occurs in texture versions before 7.2
-	if depth == 0 {
-		depth = 1
-	}
-
-	// Only support 1 ZSlice. No known Source game can use > 1 zslices
-	numZSlice := uint16(1)
-	bufferEnd := len(buffer)
-
-	storedFormat := format.Format(header.HighResImageFormat)
-	mipmapSizes := internal.ComputeMipmapSizes(int(header.MipmapCount), int(header.Width), int(header.Height))
-
-	// Iterate mipmap; smallest to largest
-	mipMaps := make([][][][][]uint8, header.MipmapCount)
-	for mipmapIdx := int8(header.MipmapCount - 1); mipmapIdx >= int8(0); mipmapIdx-- {
-		// Frame by frame; first to last
-		frames := make([][][][]uint8, header.Frames)
-		for frameIdx := uint16(0); frameIdx < header.Frames; frameIdx++ {
-			faces := make([][][]uint8, 1)
-			// Face by face; first to last
-			// @TODO is this correct to use depth? How to know how many faces there are
-			for faceIdx := uint16(0); faceIdx < depth; faceIdx++ {
-				zSlices := make([][]uint8, 1)
-				// Z Slice by Z Slice; first to last
-				// @TODO wtf is a z slice, and how do we know how many there are
-				for sliceIdx := uint16(0); sliceIdx < numZSlice; sliceIdx++ {
-					bufferSize := internal.ComputeSizeOfMipmapData(
-						mipmapSizes[mipmapIdx][0],
-						mipmapSizes[mipmapIdx][1],
-						storedFormat)
-					if len(buffer) < bufferEnd-bufferSize {
-						return mipMaps, ErrorMipmapSizeMismatch
-					}
-					img := buffer[bufferEnd-bufferSize : bufferEnd]
-
-					bufferEnd -= bufferSize
-					zSlices[sliceIdx] = img
-				}
-				faces[faceIdx] = zSlices
-			}
-			frames[frameIdx] = faces
-		}
-		mipMaps[mipmapIdx] = frames
-
-		// Ensure that we maintain aspect ratio when scaling up mipmaps
+// lowResImageDataSize returns the size of the low resolution thumbnail in bytes,
+// or 0 if there is none
+func lowResImageDataSize(header *Header) int {
+	if !header.HasLowResImage() {
+		return 0
 	}
 
-	return mipMaps, nil
+	return internal.ComputeSizeOfMipmapData(
+		int(header.LowResImageWidth),
+		int(header.LowResImageHeight),
+		format.Format(header.LowResImageFormat))
 }
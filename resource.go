@@ -0,0 +1,131 @@
+package vtf
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	// ResourceFlagNoDataChunk marks a resource whose 4 byte value is stored
+	// directly in its directory entry, rather than at an offset
+	ResourceFlagNoDataChunk = 0x02
+)
+
+// Known resource tags
+var (
+	// ResourceTagLowResImage is the low resolution thumbnail
+	ResourceTagLowResImage = [3]byte{0x01, 0, 0}
+	// ResourceTagHighResImage is the high resolution mipmap data
+	ResourceTagHighResImage = [3]byte{0x30, 0, 0}
+	// ResourceTagParticleSheet is particle sheet sequence data
+	ResourceTagParticleSheet = [3]byte{0x10, 0, 0}
+	// ResourceTagCRC is a CRC32 checksum of the source image
+	ResourceTagCRC = [3]byte{'C', 'R', 'C'}
+	// ResourceTagLOD is the texture level of detail clamp
+	ResourceTagLOD = [3]byte{'L', 'O', 'D'}
+	// ResourceTagTSO is extended texture settings flags
+	ResourceTagTSO = [3]byte{'T', 'S', 'O'}
+	// ResourceTagKeyValues is arbitrary KeyValues text data
+	ResourceTagKeyValues = [3]byte{'K', 'V', 'D'}
+)
+
+var (
+	// ErrorInvalidResource occurs when a resource entry or its data lies outside of the file
+	ErrorInvalidResource = errors.New("invalid resource")
+)
+
+// Resource is a single entry of the 7.3+ resource directory
+type Resource struct {
+	// Tag identifies the type of resource
+	Tag [3]byte
+	// Flags are resource flags; see ResourceFlagNoDataChunk
+	Flags uint8
+	// Offset is the location of the resource data from the start of the file.
+	// Unset for resources flagged ResourceFlagNoDataChunk
+	Offset uint32
+	// Data is the resource data, without its length prefix.
+	// For resources flagged ResourceFlagNoDataChunk this is the 4 byte inline value.
+	// Image resources leave this empty; their data is available as LowResImageData & HighResImageData
+	Data []byte
+}
+
+// IsImage returns whether this resource is the low or high resolution image data
+func (resource *Resource) IsImage() bool {
+	return resource.Tag == ResourceTagLowResImage || resource.Tag == ResourceTagHighResImage
+}
+
+// Resources returns all resources of a 7.3+ vtf, in directory order.
+// Returns no resources for earlier versions
+func (vtf *Vtf) Resources() []Resource {
+	return vtf.resources
+}
+
+// Resource returns the first resource with a matching tag, or nil if
+// there is none
+func (vtf *Vtf) Resource(tag [3]byte) *Resource {
+	return resourceByTag(vtf.resources, tag)
+}
+
+// SetResource adds a resource, replacing any existing resource with the same tag.
+// Image resources are always generated when writing, so cannot be set.
+func (vtf *Vtf) SetResource(resource Resource) {
+	if resource.IsImage() {
+		return
+	}
+	if existing := vtf.Resource(resource.Tag); existing != nil {
+		*existing = resource
+		return
+	}
+	vtf.resources = append(vtf.resources, resource)
+}
+
+// CRC returns the value of the CRC resource, if present
+func (vtf *Vtf) CRC() (uint32, bool) {
+	return vtf.inlineResourceValue(ResourceTagCRC)
+}
+
+// LevelOfDetail returns the U & V level of detail clamps of the LOD resource, if present
+func (vtf *Vtf) LevelOfDetail() (uint8, uint8, bool) {
+	resource := vtf.Resource(ResourceTagLOD)
+	if resource == nil || len(resource.Data) < 2 {
+		return 0, 0, false
+	}
+
+	return resource.Data[0], resource.Data[1], true
+}
+
+// TextureSettings returns the extended flags of the TSO resource, if present
+func (vtf *Vtf) TextureSettings() (uint32, bool) {
+	return vtf.inlineResourceValue(ResourceTagTSO)
+}
+
+// KeyValues returns the text of the KVD resource, if present
+func (vtf *Vtf) KeyValues() (string, bool) {
+	resource := vtf.Resource(ResourceTagKeyValues)
+	if resource == nil {
+		return "", false
+	}
+
+	return string(resource.Data), true
+}
+
+// resourceByTag finds the first resource with a matching tag
+func resourceByTag(resources []Resource, tag [3]byte) *Resource {
+	for idx := range resources {
+		if resources[idx].Tag == tag {
+			return &resources[idx]
+		}
+	}
+
+	return nil
+}
+
+// inlineResourceValue reads the 4 byte value of a resource
+func (vtf *Vtf) inlineResourceValue(tag [3]byte) (uint32, bool) {
+	resource := vtf.Resource(tag)
+	if resource == nil || len(resource.Data) < 4 {
+		return 0, false
+	}
+
+	return binary.LittleEndian.Uint32(resource.Data), true
+}
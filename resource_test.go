@@ -0,0 +1,161 @@
+package vtf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestVtf_Resources(t *testing.T) {
+	expected := newTestVtf(5, 1)
+	expected.SetResource(Resource{Tag: ResourceTagCRC, Flags: ResourceFlagNoDataChunk, Data: []byte{0x78, 0x56, 0x34, 0x12}})
+	expected.SetResource(Resource{Tag: ResourceTagLOD, Flags: ResourceFlagNoDataChunk, Data: []byte{3, 4, 0, 0}})
+	expected.SetResource(Resource{Tag: ResourceTagKeyValues, Data: []byte("\"Information\" {}")})
+	expected.SetResource(Resource{Tag: [3]byte{'X', 'Y', 'Z'}, Data: []byte{9, 8, 7}})
+
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, expected); err != nil {
+		t.Fatal(err)
+	}
+	fileSize := buf.Len()
+	actual, err := ReadFromStream(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(actual.Resources()) != 6 {
+		t.Fatalf("expected 6 resources, got %d", len(actual.Resources()))
+	}
+	if crc, ok := actual.CRC(); !ok || crc != 0x12345678 {
+		t.Errorf("unexpected CRC %x", crc)
+	}
+	if u, v, ok := actual.LevelOfDetail(); !ok || u != 3 || v != 4 {
+		t.Errorf("unexpected LOD %d %d", u, v)
+	}
+	if kv, ok := actual.KeyValues(); !ok || kv != "\"Information\" {}" {
+		t.Errorf("unexpected KeyValues %s", kv)
+	}
+	if _, ok := actual.TextureSettings(); ok {
+		t.Error("expected no TSO resource")
+	}
+	if unknown := actual.Resource([3]byte{'X', 'Y', 'Z'}); unknown == nil || !bytes.Equal(unknown.Data, []byte{9, 8, 7}) {
+		t.Error("expected unknown resource to be preserved")
+	}
+
+	highRes := actual.Resource(ResourceTagHighResImage)
+	if highRes == nil {
+		t.Fatal("expected high resolution image resource")
+	}
+	if int(highRes.Offset)+len(actual.HighResImageData()[0][0][0][0]) > fileSize {
+		t.Errorf("unexpected high resolution offset %d", highRes.Offset)
+	}
+	if actual.Resource(ResourceTagLowResImage) == nil {
+		t.Error("expected low resolution image resource")
+	}
+}
+
+func TestVtf_Resources_PreV73(t *testing.T) {
+	vtf, err := ReadFromFile("samples/read/test.vtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vtf.Resources()) != 0 {
+		t.Errorf("expected no resources, got %d", len(vtf.Resources()))
+	}
+	if vtf.Resource(ResourceTagHighResImage) != nil {
+		t.Error("expected no high resolution resource")
+	}
+}
+
+func TestReadFromStream_InvalidResource(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(data []byte)
+	}{
+		{
+			name: "too many entries",
+			modify: func(data []byte) {
+				binary.LittleEndian.PutUint32(data[headerOffset("NumResource"):], 200)
+			},
+		},
+		{
+			name: "data offset outside file",
+			modify: func(data []byte) {
+				// Second entry is the KeyValues resource
+				binary.LittleEndian.PutUint32(data[headerSize73+resourceEntrySize+4:], 0xfffffff0)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestVtf(5, 1)
+			v.SetResource(Resource{Tag: ResourceTagKeyValues, Data: []byte("abc")})
+			var buf bytes.Buffer
+			if err := WriteToStream(&buf, v); err != nil {
+				t.Fatal(err)
+			}
+			data := buf.Bytes()
+			tt.modify(data)
+
+			_, err := ReadFromStream(bytes.NewReader(data))
+			if !errors.Is(err, ErrorInvalidResource) {
+				t.Errorf("expected %v, got %v", ErrorInvalidResource, err)
+			}
+		})
+	}
+}
+
+func TestWriteToStream_InvalidInlineResource(t *testing.T) {
+	v := newTestVtf(5, 1)
+	v.SetResource(Resource{Tag: ResourceTagCRC, Flags: ResourceFlagNoDataChunk, Data: []byte{1}})
+
+	err := WriteToStream(&bytes.Buffer{}, v)
+	if !errors.Is(err, ErrorInvalidResource) {
+		t.Errorf("expected %v, got %v", ErrorInvalidResource, err)
+	}
+}
+
+func TestReadFromStream_ResourceAfterImageData(t *testing.T) {
+	expected := newTestVtf(5, 2)
+	expected.SetResource(Resource{Tag: ResourceTagKeyValues, Data: []byte("trailing")})
+
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, expected); err != nil {
+		t.Fatal(err)
+	}
+
+	// Move the KeyValues data after the high resolution data, as other tools may write it
+	data := buf.Bytes()
+	entries := data[headerSize73:]
+	lowResOffset := binary.LittleEndian.Uint32(entries[4:])
+	keyValuesOffset := binary.LittleEndian.Uint32(entries[resourceEntrySize+4:])
+	highResOffset := binary.LittleEndian.Uint32(entries[2*resourceEntrySize+4:])
+	keyValues := append([]byte{}, data[keyValuesOffset:highResOffset]...)
+	highRes := append([]byte{}, data[highResOffset:]...)
+
+	moved := append([]byte{}, data[:keyValuesOffset]...)
+	moved = append(moved, highRes...)
+	moved = append(moved, keyValues...)
+	binary.LittleEndian.PutUint32(moved[headerSize73+resourceEntrySize+4:], keyValuesOffset+uint32(len(highRes)))
+	binary.LittleEndian.PutUint32(moved[headerSize73+2*resourceEntrySize+4:], keyValuesOffset)
+	if lowResOffset >= keyValuesOffset {
+		t.Fatal("unexpected resource layout")
+	}
+
+	actual, err := ReadFromStream(bytes.NewReader(moved))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual.HighResImageData(), expected.HighResImageData()) {
+		t.Error("high resolution data mismatch")
+	}
+	if !bytes.Equal(actual.LowResImageData(), expected.LowResImageData()) {
+		t.Error("low resolution data mismatch")
+	}
+	if kv, _ := actual.KeyValues(); kv != "trailing" {
+		t.Errorf("unexpected KeyValues %s", kv)
+	}
+}
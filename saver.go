@@ -0,0 +1,32 @@
+package vtf
+
+import (
+	"io"
+	"os"
+)
+
+// WriteToStream writes a vtf to a standard
+// io.Writer stream
+func WriteToStream(stream io.Writer, vtf *Vtf) error {
+	writer := &Writer{
+		stream: stream,
+	}
+
+	return writer.Write(vtf)
+}
+
+// WriteToFile is a wrapper for WriteToStream to write directly to the
+// filesystem. Exists for convenience
+func WriteToFile(filepath string, vtf *Vtf) error {
+	file, err := os.Create(filepath)
+	if err != nil {
+		return err
+	}
+
+	if err := WriteToStream(file, vtf); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
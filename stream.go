@@ -0,0 +1,282 @@
+package vtf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// streamBufferSize is the largest read whose buffer is allocated up front.
+// Larger buffers grow as data arrives, so a corrupt header can only
+// allocate as much as the stream holds.
+const streamBufferSize = 1 << 20
+
+var (
+	// ErrorStreamConsumed occurs when reading the surfaces of a stream more than once
+	ErrorStreamConsumed = errors.New("stream surfaces have already been read")
+)
+
+// SurfaceFunc receives the raw data of a single slice of a mipmap, frame & face.
+// Returning an error stops reading, and that error is returned.
+type SurfaceFunc func(mipmap int, frame int, face int, slice int, data []byte) error
+
+// StreamReader reads a vtf sequentially from a stream, without buffering the whole file.
+// The header, resources & low resolution thumbnail are read as soon as it is created.
+// Mipmaps are then passed to a callback as they arrive; smallest first, so a preview
+// can be drawn before the largest mipmaps have been received.
+type StreamReader struct {
+	reader   *Reader
+	vtf      *Vtf
+	layout   *imageLayout
+	position int64
+	// pending are resources stored after the high resolution image data
+	pending []streamChunk
+	done    bool
+}
+
+// streamChunk is a piece of non mipmap data at a known offset of the stream
+type streamChunk struct {
+	offset int64
+	// resource is the index of the resource this chunk holds, or -1 for the thumbnail
+	resource int
+}
+
+// NewStreamReader reads the header, resources & low resolution thumbnail of a vtf
+// from a stream. WithMaxMipLevel & WithMaxDimension limit which mipmaps are read.
+// Without a resource directory, mipmaps are assumed to immediately follow the thumbnail.
+func NewStreamReader(stream io.Reader, options ...ReadOption) (*StreamReader, error) {
+	reader := &Reader{
+		stream:  stream,
+		options: newReaderOptions(options),
+	}
+
+	header, directory, err := reader.readHeaderAndDirectory()
+	if err != nil {
+		return nil, err
+	}
+
+	streamReader := &StreamReader{
+		reader:   reader,
+		vtf:      &Vtf{header: *header, resources: parseResourceDirectory(directory)},
+		layout:   computeImageLayout(header),
+		position: int64(header.HeaderSize),
+	}
+
+	lowResOffset := int64(header.HeaderSize)
+	highResOffset := lowResOffset + int64(lowResImageDataSize(header))
+	var chunks []streamChunk
+	for idx, resource := range streamReader.vtf.resources {
+		switch {
+		case resource.Tag == ResourceTagLowResImage:
+			lowResOffset = int64(resource.Offset)
+		case resource.Tag == ResourceTagHighResImage:
+			highResOffset = int64(resource.Offset)
+		case resource.Flags&ResourceFlagNoDataChunk == 0:
+			chunks = append(chunks, streamChunk{offset: int64(resource.Offset), resource: idx})
+		}
+	}
+	if header.HasLowResImage() && (header.NumResource == 0 || resourceByTag(streamReader.vtf.resources, ResourceTagLowResImage) != nil) {
+		chunks = append(chunks, streamChunk{offset: lowResOffset, resource: -1})
+	}
+	sort.SliceStable(chunks, func(i, j int) bool {
+		return chunks[i].offset < chunks[j].offset
+	})
+
+	if highResOffset < int64(header.HeaderSize) {
+		return nil, fieldError(FieldHighResImageData, highResOffset, fmt.Errorf("%w: data starts within the %d byte header", ErrorMipmapSizeMismatch, header.HeaderSize))
+	}
+	streamReader.layout.moveTo(highResOffset)
+	streamReader.layout.truncate(reader.readerOptions().mipmapCount(streamReader.layout.sizes))
+
+	// Everything stored before the mipmaps is read now; the rest once they have been read
+	streamReader.pending = chunks
+	if err := streamReader.readChunks(highResOffset); err != nil {
+		return nil, err
+	}
+
+	return streamReader, nil
+}
+
+// Vtf returns the vtf read so far. It holds the header, low resolution thumbnail
+// and resources, but no mipmaps. Resources stored after the mipmaps are only
+// present once every mipmap has been read.
+func (streamReader *StreamReader) Vtf() *Vtf {
+	return streamReader.vtf
+}
+
+// Header returns the vtf Header
+func (streamReader *StreamReader) Header() Header {
+	return streamReader.vtf.header
+}
+
+// ReadSurfaces reads every mipmap that should be loaded, calling fn with each
+// slice of each frame & face as soon as it has been read. Mipmaps are read smallest
+// first, then frame, face & slice. Data passed to fn is not reused, so may be retained.
+// Surfaces can only be read once. When reading with WithLenient, a stream that ends
+// early stops reading without an error, and is reported by Vtf().Warnings.
+func (streamReader *StreamReader) ReadSurfaces(fn SurfaceFunc) error {
+	if streamReader.done {
+		return ErrorStreamConsumed
+	}
+	streamReader.done = true
+
+	layout := streamReader.layout
+	if err := streamReader.skipTo(layout.start); err != nil {
+		return streamReader.recover(fieldError(FieldHighResImageData, layout.start, err))
+	}
+	for mipmapIdx, size := range layout.sizes {
+		for frameIdx := 0; frameIdx < layout.frames; frameIdx++ {
+			for faceIdx := 0; faceIdx < layout.faces; faceIdx++ {
+				for sliceIdx := 0; sliceIdx < size[2]; sliceIdx++ {
+					offset := streamReader.position
+					data, err := streamReader.readData(int64(layout.surfaceSizes[mipmapIdx]))
+					if err != nil {
+						// Lenient reads end at the first surface that is cut short
+						return streamReader.recover(surfaceError(mipmapIdx, frameIdx, faceIdx, sliceIdx, offset, fmt.Errorf("%w: %s", ErrorMipmapSizeMismatch, err)))
+					}
+					if err := fn(mipmapIdx, frameIdx, faceIdx, sliceIdx, data); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	// Resources after skipped mipmaps are not read, as they are not needed
+	if len(layout.sizes) < int(streamReader.vtf.header.MipmapCount) {
+		return nil
+	}
+
+	return streamReader.readChunks(math.MaxInt64)
+}
+
+// readChunks reads every pending chunk before an offset, in offset order.
+// Lenient reads drop the resources that cannot be read
+func (streamReader *StreamReader) readChunks(before int64) error {
+	for len(streamReader.pending) > 0 && streamReader.pending[0].offset < before {
+		chunk := streamReader.pending[0]
+		streamReader.pending = streamReader.pending[1:]
+
+		err := streamReader.readChunk(chunk)
+		if err == nil {
+			continue
+		}
+		if err := streamReader.recover(err); err != nil {
+			return err
+		}
+		if chunk.resource >= 0 {
+			streamReader.dropResource(chunk.resource)
+		}
+	}
+
+	return nil
+}
+
+// dropResource removes a resource that could not be read, keeping the
+// resources of pending chunks in place
+func (streamReader *StreamReader) dropResource(resource int) {
+	resources := streamReader.vtf.resources
+	streamReader.vtf.resources = append(resources[:resource:resource], resources[resource+1:]...)
+	for idx := range streamReader.pending {
+		if streamReader.pending[idx].resource > resource {
+			streamReader.pending[idx].resource--
+		}
+	}
+}
+
+// recover records a problem as a warning of the vtf when reading leniently,
+// so reading can continue. Otherwise the problem is returned
+func (streamReader *StreamReader) recover(err error) error {
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		return err
+	}
+	if err := streamReader.reader.recover(parseErr); err != nil {
+		return err
+	}
+	streamReader.vtf.warnings = streamReader.reader.warnings
+
+	return nil
+}
+
+// readChunk reads the thumbnail or the data of a resource
+func (streamReader *StreamReader) readChunk(chunk streamChunk) error {
+	field := FieldLowResImageData
+	if chunk.resource >= 0 {
+		field = resourceField(streamReader.vtf.resources[chunk.resource].Tag)
+	}
+	if err := streamReader.skipTo(chunk.offset); err != nil {
+		return fieldError(field, chunk.offset, err)
+	}
+
+	if chunk.resource < 0 {
+		size := lowResImageDataSize(&streamReader.vtf.header)
+		if err := streamReader.reader.hold(int64(size)); err != nil {
+			return err
+		}
+		data, err := streamReader.readData(int64(size))
+		if err != nil {
+			return fieldError(field, chunk.offset, fmt.Errorf("%w: %s", ErrorImageDataTooSmall, err))
+		}
+		streamReader.vtf.lowResolutionImageData = data
+		return nil
+	}
+
+	resource := &streamReader.vtf.resources[chunk.resource]
+	var length [4]byte
+	if err := streamReader.read(length[:]); err != nil {
+		return fieldError(field, chunk.offset, fmt.Errorf("%w: %s", ErrorInvalidResource, err))
+	}
+	size := int64(binary.LittleEndian.Uint32(length[:]))
+	if err := streamReader.reader.hold(size); err != nil {
+		return err
+	}
+	data, err := streamReader.readData(size)
+	if err != nil {
+		return fieldError(field, chunk.offset, fmt.Errorf("%w: data is truncated: %s", ErrorInvalidResource, err))
+	}
+	resource.Data = data
+
+	return nil
+}
+
+// skipTo discards stream data up to an offset. Data already read cannot be returned to
+func (streamReader *StreamReader) skipTo(offset int64) error {
+	if offset < streamReader.position {
+		return fmt.Errorf("%w: data at offset %d overlaps data already read", ErrorInvalidResource, offset)
+	}
+
+	skipped, err := io.CopyN(io.Discard, streamReader.reader.stream, offset-streamReader.position)
+	streamReader.position += skipped
+
+	return err
+}
+
+// readData reads size bytes from the stream
+func (streamReader *StreamReader) readData(size int64) ([]byte, error) {
+	if size <= streamBufferSize {
+		data := make([]byte, size)
+		return data, streamReader.read(data)
+	}
+
+	buffer := bytes.NewBuffer(make([]byte, 0, streamBufferSize))
+	n, err := io.CopyN(buffer, streamReader.reader.stream, size)
+	streamReader.position += n
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return buffer.Bytes(), err
+}
+
+// read fills a buffer from the stream
+func (streamReader *StreamReader) read(data []byte) error {
+	n, err := io.ReadFull(streamReader.reader.stream, data)
+	streamReader.position += int64(n)
+
+	return err
+}
@@ -0,0 +1,139 @@
+package vtf
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"testing/iotest"
+)
+
+// streamedSurface is a single surface received from a StreamReader
+type streamedSurface struct {
+	mipmap, frame, face, slice int
+	data                       []byte
+}
+
+func TestStreamReader(t *testing.T) {
+	for _, version := range []uint32{2, 5} {
+		header := newTestVtf(version, 2).Header()
+		header.Flags |= FlagEnvironmentMap
+		v := newTestVtfFromHeader(header)
+		v.SetResource(Resource{Tag: ResourceTagKeyValues, Data: []byte("key value")})
+		var buf bytes.Buffer
+		if err := WriteToStream(&buf, v); err != nil {
+			t.Fatal(err)
+		}
+
+		stream, err := NewStreamReader(iotest.OneByteReader(bytes.NewReader(buf.Bytes())))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(stream.Vtf().LowResImageData(), v.LowResImageData()) {
+			t.Errorf("7.%d: thumbnail differs from the written texture", version)
+		}
+
+		var surfaces []streamedSurface
+		err = stream.ReadSurfaces(func(mipmap int, frame int, face int, slice int, data []byte) error {
+			surfaces = append(surfaces, streamedSurface{mipmap, frame, face, slice, data})
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := 4 * 2 * header.FaceCount(); len(surfaces) != expected {
+			t.Fatalf("7.%d: expected %d surfaces, got %d", version, expected, len(surfaces))
+		}
+		for idx, surface := range surfaces {
+			if idx > 0 && surface.mipmap < surfaces[idx-1].mipmap {
+				t.Errorf("7.%d: mipmaps are not smallest first", version)
+			}
+			if !bytes.Equal(surface.data, v.Slice(surface.mipmap, surface.frame, surface.face, surface.slice)) {
+				t.Errorf("7.%d: surface %d differs from the written texture", version, idx)
+			}
+		}
+		if version >= 3 {
+			if kv, _ := stream.Vtf().KeyValues(); kv != "key value" {
+				t.Errorf("7.%d: unexpected key values %q", version, kv)
+			}
+		}
+
+		if err := stream.ReadSurfaces(func(int, int, int, int, []byte) error { return nil }); !errors.Is(err, ErrorStreamConsumed) {
+			t.Errorf("expected %v, got %v", ErrorStreamConsumed, err)
+		}
+	}
+}
+
+func TestStreamReader_Truncated(t *testing.T) {
+	v := newTestVtf(5, 1)
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, v); err != nil {
+		t.Fatal(err)
+	}
+	// Cut the file part way through the largest mipmap
+	data := buf.Bytes()[:buf.Len()-len(v.Image())/2]
+
+	stream, err := NewStreamReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received []int
+	err = stream.ReadSurfaces(func(mipmap int, frame int, face int, slice int, data []byte) error {
+		received = append(received, mipmap)
+		return nil
+	})
+	if !errors.Is(err, ErrorMipmapSizeMismatch) {
+		t.Errorf("expected %v, got %v", ErrorMipmapSizeMismatch, err)
+	}
+	if len(received) != 3 {
+		t.Errorf("expected the 3 smallest mipmaps before the error, got %v", received)
+	}
+}
+
+func TestStreamReader_MipmapLimit(t *testing.T) {
+	v := newTestVtf(2, 1)
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, v); err != nil {
+		t.Fatal(err)
+	}
+
+	source := bytes.NewReader(buf.Bytes())
+	stream, err := NewStreamReader(source, WithMaxMipLevel(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	if err := stream.ReadSurfaces(func(int, int, int, int, []byte) error {
+		count++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 surfaces, got %d", count)
+	}
+	if unread := len(v.HighResImageData()[2][0][0][0]) + len(v.Image()); source.Len() != unread {
+		t.Errorf("expected the largest mipmaps to be left unread, %d bytes remain", source.Len())
+	}
+}
+
+func TestStreamReader_CallbackError(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, newTestVtf(5, 1)); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := NewStreamReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = stream.ReadSurfaces(func(int, int, int, int, []byte) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("expected reading to stop after 1 call, got %d calls and %v", calls, err)
+	}
+}
@@ -0,0 +1,88 @@
+package vtf
+
+import (
+	"image"
+
+	"github.com/galaco/vtf/format"
+	"github.com/galaco/vtf/internal"
+)
+
+// Surface is a single Z slice of a mipmap, frame & face of the high resolution
+// image data, with everything needed to interpret it
+type Surface struct {
+	// Mip, Frame, Face & Slice locate the surface. Mipmaps are indexed smallest
+	// to largest, as in HighResImageData
+	Mip   int
+	Frame int
+	Face  int
+	Slice int
+	// Width & Height of the surface in pixels
+	Width  int
+	Height int
+	// Depth is the number of Z slices of the mipmap the surface belongs to
+	Depth int
+	// Format the data is stored in
+	Format format.Format
+	// Data is the raw surface data
+	Data []byte
+}
+
+// Image decodes the surface; see DecodeImageData
+func (surface *Surface) Image() (image.Image, error) {
+	return DecodeImageData(surface.Data, surface.Width, surface.Height, surface.Format)
+}
+
+// SurfaceVisitor receives every surface of a vtf.
+// Returning an error stops visiting, and that error is returned.
+type SurfaceVisitor func(surface *Surface) error
+
+// Surface returns a single Z slice of a mipmap, frame & face.
+// Vtfs read on demand read the surface from their source on every call.
+func (vtf *Vtf) Surface(mipmap int, frame int, face int, slice int) (*Surface, error) {
+	data, err := vtf.SliceData(mipmap, frame, face, slice)
+	if err != nil {
+		return nil, err
+	}
+	size := vtf.mipmapSizes()[mipmap]
+
+	return &Surface{
+		Mip:    mipmap,
+		Frame:  frame,
+		Face:   face,
+		Slice:  slice,
+		Width:  size[0],
+		Height: size[1],
+		Depth:  size[2],
+		Format: format.Format(vtf.header.HighResImageFormat),
+		Data:   data,
+	}, nil
+}
+
+// EachSurface calls fn with every loaded surface, in the order they are stored;
+// smallest mipmap first, then frame, face & Z slice.
+func (vtf *Vtf) EachSurface(fn SurfaceVisitor) error {
+	sizes := vtf.mipmapSizes()
+	for mipmap := 0; mipmap < vtf.LoadedMipmapCount(); mipmap++ {
+		for frame := 0; frame < int(vtf.header.Frames); frame++ {
+			for face := 0; face < vtf.header.FaceCount(); face++ {
+				for slice := 0; slice < sizes[mipmap][2]; slice++ {
+					surface, err := vtf.Surface(mipmap, frame, face, slice)
+					if err != nil {
+						return err
+					}
+					if err := fn(surface); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// mipmapSizes returns the width, height & depth of every mipmap the Header
+// describes, smallest first
+func (vtf *Vtf) mipmapSizes() [][3]int {
+	return internal.ComputeMipmapSizes(int(vtf.header.MipmapCount), int(vtf.header.Width), int(vtf.header.Height), vtf.header.SliceCount())
+}
@@ -0,0 +1,103 @@
+package vtf
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/galaco/vtf/format"
+)
+
+func TestVtf_Surface(t *testing.T) {
+	header := newTestVtf(5, 2).Header()
+	header.Depth = 4
+	v := newTestVtfFromHeader(header)
+
+	surface, err := v.Surface(2, 1, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if surface.Mip != 2 || surface.Frame != 1 || surface.Face != 0 || surface.Slice != 1 {
+		t.Errorf("unexpected surface location: %+v", surface)
+	}
+	if surface.Width != 4 || surface.Height != 2 || surface.Depth != 2 || surface.Format != format.RGBA8888 {
+		t.Errorf("expected a 4x2x2 RGBA8888 surface, got %dx%dx%d %d", surface.Width, surface.Height, surface.Depth, surface.Format)
+	}
+	if !bytes.Equal(surface.Data, v.Slice(2, 1, 0, 1)) {
+		t.Error("surface data differs from Slice")
+	}
+
+	if _, err := v.Surface(2, 0, 0, 2); !errors.Is(err, ErrorInvalidDimensions) {
+		t.Errorf("expected %v, got %v", ErrorInvalidDimensions, err)
+	}
+}
+
+func TestVtf_Surface_Image(t *testing.T) {
+	expected := color.NRGBA{R: 10, G: 20, B: 30, A: 255}
+	v, err := NewFromImages([]image.Image{solidImage(8, 8, expected)}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	surface, err := v.Surface(v.LoadedMipmapCount()-1, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := surface.Image()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 8 || img.Bounds().Dy() != 8 || color.NRGBAModel.Convert(img.At(3, 3)) != expected {
+		t.Error("decoded surface differs from the source image")
+	}
+}
+
+func TestVtf_EachSurface(t *testing.T) {
+	header := newTestVtf(5, 2).Header()
+	header.Flags |= FlagEnvironmentMap
+	v := newTestVtfFromHeader(header)
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, v); err != nil {
+		t.Fatal(err)
+	}
+	lazy, err := ReadFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, texture := range []*Vtf{v, lazy} {
+		var visited []*Surface
+		err := texture.EachSurface(func(surface *Surface) error {
+			visited = append(visited, surface)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Surfaces are visited in file order, so their data follows the written file
+		expected := int(header.MipmapCount) * int(header.Frames) * header.FaceCount()
+		if len(visited) != expected {
+			t.Fatalf("expected %d surfaces, got %d", expected, len(visited))
+		}
+		offset := int(lazy.layout.start)
+		for _, surface := range visited {
+			if !bytes.Equal(buf.Bytes()[offset:offset+len(surface.Data)], surface.Data) {
+				t.Errorf("surface %d %d %d %d is out of order", surface.Mip, surface.Frame, surface.Face, surface.Slice)
+			}
+			offset += len(surface.Data)
+		}
+	}
+
+	stop := errors.New("stop")
+	visits := 0
+	err = v.EachSurface(func(surface *Surface) error {
+		visits++
+		return stop
+	})
+	if err != stop || visits != 1 {
+		t.Errorf("expected visiting to stop at the first error, got %v after %d visits", err, visits)
+	}
+}
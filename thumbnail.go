@@ -0,0 +1,75 @@
+package vtf
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/galaco/vtf/format"
+	"github.com/galaco/vtf/internal"
+)
+
+const (
+	// lowResImageMaxSize is the size of the largest axis of a standard thumbnail
+	lowResImageMaxSize = 16
+)
+
+// GenerateLowResImage creates the standard low resolution thumbnail from the largest
+// loaded mipmap of the first frame, replacing any existing thumbnail.
+// The thumbnail is Dxt1, with its largest axis at 16 and aspect ratio preserved.
+// Textures smaller than 16 use their own size.
+func (vtf *Vtf) GenerateLowResImage() error {
+	img, err := vtf.DecodeMipmap(vtf.LoadedMipmapCount()-1, 0)
+	if err != nil {
+		return err
+	}
+
+	return vtf.setLowResImage(img, nil)
+}
+
+// setLowResImage resamples an image into the standard thumbnail
+func (vtf *Vtf) setLowResImage(img image.Image, options *MipmapOptions) error {
+	bounds := img.Bounds()
+	if bounds.Dx() <= 0 || bounds.Dy() <= 0 {
+		return fmt.Errorf("%w: width=%d, height=%d", ErrorInvalidDimensions, bounds.Dx(), bounds.Dy())
+	}
+	width, height := lowResImageSize(bounds.Dx(), bounds.Dy())
+
+	thumbnail := toNRGBA(img)
+	if width != bounds.Dx() || height != bounds.Dy() {
+		thumbnail = internal.Resize(thumbnail, width, height, options.resampleFilter(), options != nil && options.SRGB)
+	}
+	data, err := EncodeImageData(thumbnail, format.Dxt1, CompressionQualityHigh)
+	if err != nil {
+		return err
+	}
+
+	vtf.header.LowResImageFormat = uint32(format.Dxt1)
+	vtf.header.LowResImageWidth = uint8(width)
+	vtf.header.LowResImageHeight = uint8(height)
+	vtf.lowResolutionImageData = data
+
+	return nil
+}
+
+// lowResImageSize scales a size so its largest axis is 16, preserving aspect ratio.
+// Sizes already within 16 are unchanged
+func lowResImageSize(width int, height int) (int, int) {
+	largest := width
+	if height > largest {
+		largest = height
+	}
+	if largest <= lowResImageMaxSize {
+		return width, height
+	}
+
+	width = (width*lowResImageMaxSize + largest/2) / largest
+	height = (height*lowResImageMaxSize + largest/2) / largest
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	return width, height
+}
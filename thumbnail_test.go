@@ -0,0 +1,122 @@
+package vtf
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/galaco/vtf/format"
+)
+
+func TestLowResImageSize(t *testing.T) {
+	tests := []struct {
+		width, height  int
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{512, 128, 16, 4},
+		{128, 512, 4, 16},
+		{256, 256, 16, 16},
+		{100, 30, 16, 5},
+		{1024, 8, 16, 1},
+		{8, 4, 8, 4},
+	}
+	for _, tt := range tests {
+		width, height := lowResImageSize(tt.width, tt.height)
+		if width != tt.expectedWidth || height != tt.expectedHeight {
+			t.Errorf("%dx%d: expected %dx%d, got %dx%d", tt.width, tt.height, tt.expectedWidth, tt.expectedHeight, width, height)
+		}
+	}
+}
+
+func TestVtf_LowResImage(t *testing.T) {
+	v, err := ReadFromFile("samples/read/test.vtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := v.LowResImage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 16, 4) {
+		t.Errorf("unexpected bounds %v", img.Bounds())
+	}
+}
+
+func TestVtf_LowResImage_HeaderFormat(t *testing.T) {
+	header := newTestVtf(2, 1).Header()
+	header.LowResImageFormat = uint32(format.RGBA8888)
+	header.LowResImageWidth = 2
+	header.LowResImageHeight = 2
+	v := newTestVtfFromHeader(header)
+
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, v); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadFromStream(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read.LowResImageData(), v.LowResImageData()) {
+		t.Errorf("low resolution data does not match")
+	}
+	img, err := read.LowResImage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := img.(*image.NRGBA).NRGBAAt(1, 0); c != (color.NRGBA{4, 5, 6, 7}) {
+		t.Errorf("unexpected colour %v", c)
+	}
+}
+
+func TestVtf_LowResImage_None(t *testing.T) {
+	for _, version := range []uint32{2, 5} {
+		v, err := NewFromImages([]image.Image{solidImage(1, 1, color.NRGBA{R: 255, A: 255})}, &BuildOptions{
+			Version:       [2]uint32{7, version},
+			NoLowResImage: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := WriteToStream(&buf, v); err != nil {
+			t.Fatal(err)
+		}
+		read, err := ReadFromStream(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if read.Header().HasLowResImage() || len(read.LowResImageData()) != 0 {
+			t.Errorf("7.%d: expected no low resolution image", version)
+		}
+		if _, err := read.LowResImage(); !errors.Is(err, ErrorNoLowResImage) {
+			t.Errorf("7.%d: expected %v, got %v", version, ErrorNoLowResImage, err)
+		}
+	}
+}
+
+func TestVtf_GenerateLowResImage(t *testing.T) {
+	v, err := NewFromImages([]image.Image{solidImage(64, 16, color.NRGBA{G: 255, A: 255})}, &BuildOptions{NoLowResImage: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.GenerateLowResImage(); err != nil {
+		t.Fatal(err)
+	}
+
+	header := v.Header()
+	if header.LowResImageFormat != uint32(format.Dxt1) || header.LowResImageWidth != 16 || header.LowResImageHeight != 4 {
+		t.Errorf("unexpected thumbnail %dx%d format %d", header.LowResImageWidth, header.LowResImageHeight, header.LowResImageFormat)
+	}
+	img, err := v.LowResImage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, b, _ := img.At(3, 3).RGBA(); r != 0 || g != 0xffff || b != 0 {
+		t.Errorf("expected green thumbnail, got %d %d %d", r, g, b)
+	}
+}
@@ -1,12 +1,26 @@
 package vtf
 
+import (
+	"fmt"
+	"io"
+)
+
 // Vtf: Exported vtf format
 // Contains a Header, resources (v7.3+), low res thumbnail & high-res mipmaps
+// A vtf read by ReadFromReaderAt leaves its high-res mipmaps in the source,
+// and reads them on demand.
 type Vtf struct {
 	header                  Header
-	resources               []byte
+	resources               []Resource
 	lowResolutionImageData  []uint8
 	highResolutionImageData [][][][][]uint8 //[]mipmap[]frame[]face[]slice
+
+	// source & layout locate high-res mipmaps that have not been loaded
+	source io.ReaderAt
+	layout *imageLayout
+
+	// warnings are the problems a lenient read recovered from
+	warnings []*ParseError
 }
 
 // Header returns vtf Header
@@ -20,8 +34,40 @@ func (vtf *Vtf) LowResImageData() []uint8 {
 }
 
 // HighResImageData returns all data for all mipmaps
+// Vtfs read with a mipmap limit hold only the mipmaps that were loaded; see LoadedMipmapCount.
+// Vtfs read on demand read all mipmaps from their source on every call, and
+// return nil if that fails.
 func (vtf *Vtf) HighResImageData() [][][][][]uint8 {
-	return vtf.highResolutionImageData
+	mipmaps, _ := vtf.mipmaps()
+	return mipmaps
+}
+
+// LoadedMipmapCount returns the number of mipmaps available, smallest first.
+// This is less than Header.MipmapCount when the largest mipmaps were skipped by
+// WithMaxMipLevel or WithMaxDimension.
+func (vtf *Vtf) LoadedMipmapCount() int {
+	if vtf.layout != nil {
+		return len(vtf.layout.sizes)
+	}
+
+	return len(vtf.highResolutionImageData)
+}
+
+// mipmaps returns all data for all mipmaps, reading them from the source if
+// they are not loaded
+func (vtf *Vtf) mipmaps() ([][][][][]uint8, error) {
+	if vtf.source != nil {
+		return vtf.layout.readMipmaps(vtf.source)
+	}
+
+	return vtf.highResolutionImageData, nil
+}
+
+// Warnings returns the problems that were recovered from when this vtf was read
+// with WithLenient. Any data they concern is missing. Returns nil for a vtf that was
+// read in full
+func (vtf *Vtf) Warnings() []*ParseError {
+	return vtf.warnings
 }
 
 // Image returns raw data of the first frame of the highest resolution mipmap
@@ -33,16 +79,67 @@ func (vtf *Vtf) Image() []uint8 {
 func (vtf *Vtf) MipmapsForFrame(frame int) [][]uint8 {
 	ret := make([][]uint8, vtf.header.MipmapCount)
 
-	for idx, mipmap := range vtf.highResolutionImageData {
-		ret[idx] = mipmap[frame][0][0]
+	for idx := range ret {
+		ret[idx] = vtf.Slice(idx, frame, 0, 0)
 	}
 
 	return ret
 }
 
 // HighestResolutionImageForFrame returns the best possible resolution
-// for a single frame in the vtf
+// for a single frame in the vtf, of the mipmaps that are loaded
+// Only the first face & Z slice is returned; use Face or Slice for others
 func (vtf *Vtf) HighestResolutionImageForFrame(frame int) []byte {
-	// @TODO This currently only supports single face, single Z Slice images
-	return vtf.highResolutionImageData[vtf.header.MipmapCount-1][frame][0][0]
+	return vtf.Slice(vtf.LoadedMipmapCount()-1, frame, 0, 0)
+}
+
+// New creates a vtf from a Header, low-resolution thumbnail data and
+// high-resolution mipmap data.
+// Mipmap data is ordered the same as HighResImageData: mipmap[frame[face[slice[RGBA]]]],
+// smallest mipmap first.
+func New(header Header, lowResImageData []uint8, highResImageData [][][][][]uint8) *Vtf {
+	return &Vtf{
+		header:                  header,
+		resources:               []Resource{},
+		lowResolutionImageData:  lowResImageData,
+		highResolutionImageData: highResImageData,
+	}
+}
+
+// Face returns raw data of a single face of a mipmap & frame.
+// Volume textures return their first slice.
+// Non-cubemap textures have only face 0. Returns nil if the face does not exist
+func (vtf *Vtf) Face(mipmap int, frame int, face int) []uint8 {
+	return vtf.Slice(mipmap, frame, face, 0)
+}
+
+// Slice returns raw data of a single Z slice of a volume texture mipmap, frame & face.
+// Textures that are not volume textures have only slice 0. Returns nil if the slice does not exist
+func (vtf *Vtf) Slice(mipmap int, frame int, face int, slice int) []uint8 {
+	data, _ := vtf.SliceData(mipmap, frame, face, slice)
+	return data
+}
+
+// SliceData returns raw data of a single Z slice of a mipmap, frame & face, as Slice does.
+// Vtfs read on demand read the slice from their source on every call; any failure to
+// read it is returned.
+func (vtf *Vtf) SliceData(mipmap int, frame int, face int, slice int) ([]uint8, error) {
+	if vtf.source != nil {
+		return vtf.layout.readSurface(vtf.source, mipmap, frame, face, slice)
+	}
+
+	if mipmap >= 0 && mipmap < len(vtf.highResolutionImageData) {
+		frames := vtf.highResolutionImageData[mipmap]
+		if frame >= 0 && frame < len(frames) {
+			faces := frames[frame]
+			if face >= 0 && face < len(faces) {
+				slices := faces[face]
+				if slice >= 0 && slice < len(slices) {
+					return slices[slice], nil
+				}
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%w: mipmap %d, frame %d, face %d, slice %d does not exist", ErrorInvalidDimensions, mipmap, frame, face, slice)
 }
@@ -1,8 +1,10 @@
 package vtf
 
 import (
+	"bytes"
 	"errors"
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -126,6 +128,13 @@ func TestVtf_ValidationErrors(t *testing.T) {
 			},
 			expectedError: ErrorInvalidHeaderSize,
 		},
+		{
+			name: "unknown format",
+			modifyHeader: func(h *Header) {
+				h.HighResImageFormat = 0xffffffff
+			},
+			expectedError: ErrorUnsupportedFormat,
+		},
 	}
 
 	// Load a valid VTF file first to get a valid header
@@ -157,3 +166,52 @@ func TestVtf_ValidationErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestVtf_Slice(t *testing.T) {
+	header := newTestVtf(4, 2).Header()
+	header.Depth = 8
+	expected := newTestVtfFromHeader(header)
+
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, expected); err != nil {
+		t.Fatal(err)
+	}
+	actual, err := ReadFromStream(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(actual.HighResImageData(), expected.HighResImageData()) {
+		t.Error("high resolution data mismatch")
+	}
+
+	// Depth halves every mipmap, smallest first: 1, 2, 4, 8
+	for mipmap, expectedSlices := range []int{1, 2, 4, 8} {
+		if slices := len(actual.HighResImageData()[mipmap][0][0]); slices != expectedSlices {
+			t.Errorf("mipmap %d: expected %d slices, got %d", mipmap, expectedSlices, slices)
+		}
+	}
+	if !bytes.Equal(actual.Slice(3, 1, 0, 7), expected.HighResImageData()[3][1][0][7]) {
+		t.Error("slice data mismatch")
+	}
+	if actual.Slice(2, 1, 0, 4) != nil {
+		t.Error("expected no data for slice beyond mipmap depth")
+	}
+}
+
+func TestHeader_SliceCount(t *testing.T) {
+	header := Header{}
+	header.Version = [2]uint32{7, 1}
+	header.Depth = 4
+	if header.SliceCount() != 1 {
+		t.Errorf("expected 7.1 to have 1 slice, got %d", header.SliceCount())
+	}
+	header.Version[1] = 2
+	if header.SliceCount() != 4 {
+		t.Errorf("expected 4 slices, got %d", header.SliceCount())
+	}
+	header.Depth = 0
+	if header.SliceCount() != 1 {
+		t.Errorf("expected depth 0 to have 1 slice, got %d", header.SliceCount())
+	}
+}
@@ -0,0 +1,234 @@
+package vtf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/galaco/vtf/format"
+	"github.com/galaco/vtf/internal"
+)
+
+const (
+	// headerAlignment is the byte alignment of the header
+	headerAlignment = 16
+)
+
+var (
+	// ErrorImageDataMismatch occurs when image data does not match the layout described by the Header
+	ErrorImageDataMismatch = errors.New("image data does not match header")
+)
+
+// resourceEntry is a single entry of the 7.3+ resource directory
+type resourceEntry struct {
+	Tag    [3]byte
+	Flags  uint8
+	Offset uint32
+}
+
+// Writer writes a vtf to a stream
+type Writer struct {
+	stream io.Writer
+}
+
+// Write serialises a vtf into the stream.
+// The layout written is determined by the Header version. HeaderSize, NumResource
+// & Signature are computed; all other Header properties are written as-is and must
+// be consistent with the image data.
+// Non-image resources are only written for 7.3+; their data is placed between
+// the low and high resolution image data.
+func (writer *Writer) Write(vtf *Vtf) error {
+	header := vtf.header
+	version := header.Version[0]*10 + header.Version[1]
+	if header.Version[0] != 7 || version > 75 {
+		return fmt.Errorf("%w: %d.%d (only 7.0-7.5 supported)", ErrorUnsupportedVersion, header.Version[0], header.Version[1])
+	}
+
+	copy(header.Signature[:], vtfSignature)
+
+	lowResSize := lowResImageDataSize(&header)
+	if len(vtf.lowResolutionImageData) != lowResSize {
+		return fmt.Errorf("%w: low resolution image is %d bytes, expected %d", ErrorImageDataMismatch, len(vtf.lowResolutionImageData), lowResSize)
+	}
+
+	mipmaps, err := vtf.mipmaps()
+	if err != nil {
+		return err
+	}
+	highResSize, err := writer.validateMipmaps(&header, mipmaps)
+	if err != nil {
+		return err
+	}
+
+	// Header layout differs per version
+	var resources []resourceEntry
+	var resourceData [][]byte
+	switch {
+	case version < 72:
+		header.Depth = 0
+		header.NumResource = 0
+		header.HeaderSize = alignHeaderSize(binary.Size(header.HeaderCommon))
+	case version < 73:
+		if header.Depth == 0 {
+			header.Depth = 1
+		}
+		header.NumResource = 0
+		header.HeaderSize = alignHeaderSize(binary.Size(header.HeaderCommon) + binary.Size(header.Header72))
+	default:
+		if header.Depth == 0 {
+			header.Depth = 1
+		}
+		resources, resourceData, err = writer.buildResourceDirectory(vtf.resources, lowResSize)
+		if err != nil {
+			return err
+		}
+		header.NumResource = uint32(len(resources))
+		header.HeaderSize = alignHeaderSize(headerSize73 + len(resources)*resourceEntrySize)
+
+		offset := header.HeaderSize
+		for idx := range resources {
+			switch {
+			case resources[idx].Flags&ResourceFlagNoDataChunk != 0:
+				continue
+			case resources[idx].Tag == ResourceTagLowResImage:
+				resources[idx].Offset = offset
+				offset += uint32(lowResSize)
+			case resources[idx].Tag == ResourceTagHighResImage:
+				resources[idx].Offset = offset
+			default:
+				resources[idx].Offset = offset
+				offset += uint32(len(resourceData[idx]))
+			}
+		}
+	}
+
+	totalSize := int(header.HeaderSize) + lowResSize + highResSize
+	for _, data := range resourceData {
+		totalSize += len(data)
+	}
+	if err := (&Reader{}).validateHeader(&header, int64(totalSize)); err != nil {
+		return err
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, totalSize))
+	if err := binary.Write(buf, binary.LittleEndian, header.HeaderCommon); err != nil {
+		return err
+	}
+	if version >= 72 {
+		if err := binary.Write(buf, binary.LittleEndian, header.Header72); err != nil {
+			return err
+		}
+	}
+	if version >= 73 {
+		if err := binary.Write(buf, binary.LittleEndian, header.Header73); err != nil {
+			return err
+		}
+		buf.Write(make([]byte, headerSize73-buf.Len()))
+		if err := binary.Write(buf, binary.LittleEndian, resources); err != nil {
+			return err
+		}
+	}
+	buf.Write(make([]byte, int(header.HeaderSize)-buf.Len()))
+
+	// Low resolution thumbnail
+	buf.Write(vtf.lowResolutionImageData)
+
+	// Other resources
+	for _, data := range resourceData {
+		buf.Write(data)
+	}
+
+	// Mipmaps; smallest to largest
+	for _, mipmap := range mipmaps {
+		for _, frame := range mipmap {
+			for _, face := range frame {
+				for _, slice := range face {
+					buf.Write(slice)
+				}
+			}
+		}
+	}
+
+	_, err = writer.stream.Write(buf.Bytes())
+	return err
+}
+
+// validateMipmaps ensures that mipmap data matches the layout the header describes,
+// and returns the total size of all mipmap data.
+func (writer *Writer) validateMipmaps(header *Header, mipmaps [][][][][]uint8) (int, error) {
+	if len(mipmaps) != int(header.MipmapCount) {
+		return 0, fmt.Errorf("%w: %d mipmaps, header expects %d", ErrorImageDataMismatch, len(mipmaps), header.MipmapCount)
+	}
+
+	storedFormat := format.Format(header.HighResImageFormat)
+	mipmapSizes := internal.ComputeMipmapSizes(int(header.MipmapCount), int(header.Width), int(header.Height), header.SliceCount())
+
+	total := 0
+	for mipmapIdx, mipmap := range mipmaps {
+		if len(mipmap) != int(header.Frames) {
+			return 0, fmt.Errorf("%w: mipmap %d has %d frames, header expects %d", ErrorImageDataMismatch, mipmapIdx, len(mipmap), header.Frames)
+		}
+		expectedSize := internal.ComputeSizeOfMipmapData(mipmapSizes[mipmapIdx][0], mipmapSizes[mipmapIdx][1], storedFormat)
+		for frameIdx, frame := range mipmap {
+			if len(frame) != header.FaceCount() {
+				return 0, fmt.Errorf("%w: mipmap %d frame %d has %d faces, expected %d", ErrorImageDataMismatch, mipmapIdx, frameIdx, len(frame), header.FaceCount())
+			}
+			for faceIdx, face := range frame {
+				if len(face) != mipmapSizes[mipmapIdx][2] {
+					return 0, fmt.Errorf("%w: mipmap %d frame %d face %d has %d slices, expected %d", ErrorImageDataMismatch, mipmapIdx, frameIdx, faceIdx, len(face), mipmapSizes[mipmapIdx][2])
+				}
+				for _, slice := range face {
+					if len(slice) != expectedSize {
+						return 0, fmt.Errorf("%w: mipmap %d frame %d is %d bytes, expected %d", ErrorImageDataMismatch, mipmapIdx, frameIdx, len(slice), expectedSize)
+					}
+					total += len(slice)
+				}
+			}
+		}
+	}
+
+	return total, nil
+}
+
+// buildResourceDirectory creates the resource directory entries for a vtf. Alongside each
+// entry is its length prefixed data, or nil if the entry is inline or an image.
+func (writer *Writer) buildResourceDirectory(vtfResources []Resource, lowResSize int) ([]resourceEntry, [][]byte, error) {
+	var resources []resourceEntry
+	var resourceData [][]byte
+
+	if lowResSize > 0 {
+		resources = append(resources, resourceEntry{Tag: ResourceTagLowResImage})
+		resourceData = append(resourceData, nil)
+	}
+	for _, resource := range vtfResources {
+		if resource.IsImage() {
+			continue
+		}
+		entry := resourceEntry{Tag: resource.Tag, Flags: resource.Flags}
+		if resource.Flags&ResourceFlagNoDataChunk != 0 {
+			if len(resource.Data) != 4 {
+				return nil, nil, fmt.Errorf("%w: inline resource %v must have 4 bytes of data, has %d", ErrorInvalidResource, resource.Tag, len(resource.Data))
+			}
+			entry.Offset = binary.LittleEndian.Uint32(resource.Data)
+			resources = append(resources, entry)
+			resourceData = append(resourceData, nil)
+			continue
+		}
+		data := make([]byte, 4+len(resource.Data))
+		binary.LittleEndian.PutUint32(data, uint32(len(resource.Data)))
+		copy(data[4:], resource.Data)
+		resources = append(resources, entry)
+		resourceData = append(resourceData, data)
+	}
+	resources = append(resources, resourceEntry{Tag: ResourceTagHighResImage})
+	resourceData = append(resourceData, nil)
+
+	return resources, resourceData, nil
+}
+
+// alignHeaderSize rounds a header size up to the header alignment
+func alignHeaderSize(size int) uint32 {
+	return uint32((size + headerAlignment - 1) / headerAlignment * headerAlignment)
+}
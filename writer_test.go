@@ -0,0 +1,217 @@
+package vtf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/galaco/vtf/format"
+	"github.com/galaco/vtf/internal"
+)
+
+// newTestVtf creates a small RGBA8888 texture with a Dxt1 thumbnail,
+// where every byte of image data is distinct enough to detect misordering
+func newTestVtf(version uint32, frames int) *Vtf {
+	header := Header{}
+	header.Version = [2]uint32{7, version}
+	header.Width = 8
+	header.Height = 4
+	header.Frames = uint16(frames)
+	header.BumpmapScale = 1
+	header.Reflectivity = [3]float32{0.25, 0.5, 0.75}
+	header.HighResImageFormat = uint32(format.RGBA8888)
+	header.MipmapCount = 4
+	header.LowResImageFormat = uint32(format.Dxt1)
+	header.LowResImageWidth = 4
+	header.LowResImageHeight = 4
+
+	return newTestVtfFromHeader(header)
+}
+
+// newTestVtfFromHeader creates a texture with the layout a header describes,
+// where every byte of image data is distinct enough to detect misordering
+func newTestVtfFromHeader(header Header) *Vtf {
+	counter := byte(0)
+	fill := func(size int) []byte {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = counter
+			counter++
+		}
+		return data
+	}
+
+	lowRes := fill(internal.ComputeSizeOfMipmapData(int(header.LowResImageWidth), int(header.LowResImageHeight), format.Format(header.LowResImageFormat)))
+	sizes := internal.ComputeMipmapSizes(int(header.MipmapCount), int(header.Width), int(header.Height), header.SliceCount())
+	mipmaps := make([][][][][]uint8, header.MipmapCount)
+	for mipmapIdx := range mipmaps {
+		size := internal.ComputeSizeOfMipmapData(sizes[mipmapIdx][0], sizes[mipmapIdx][1], format.Format(header.HighResImageFormat))
+		mipmaps[mipmapIdx] = make([][][][]uint8, header.Frames)
+		for frameIdx := range mipmaps[mipmapIdx] {
+			faces := make([][][]uint8, header.FaceCount())
+			for faceIdx := range faces {
+				faces[faceIdx] = make([][]uint8, sizes[mipmapIdx][2])
+				for sliceIdx := range faces[faceIdx] {
+					faces[faceIdx][sliceIdx] = fill(size)
+				}
+			}
+			mipmaps[mipmapIdx][frameIdx] = faces
+		}
+	}
+
+	return New(header, lowRes, mipmaps)
+}
+
+func TestWriteToStream_RoundTrip(t *testing.T) {
+	for version := uint32(0); version <= 5; version++ {
+		expected := newTestVtf(version, 3)
+
+		var buf bytes.Buffer
+		if err := WriteToStream(&buf, expected); err != nil {
+			t.Fatalf("7.%d: %s", version, err)
+		}
+
+		actual, err := ReadFromStream(&buf)
+		if err != nil {
+			t.Fatalf("7.%d: %s", version, err)
+		}
+
+		actualHeader, expectedHeader := actual.Header(), expected.Header()
+		if actualHeader.HeaderSize%16 != 0 {
+			t.Errorf("7.%d: header size %d is not 16 byte aligned", version, actualHeader.HeaderSize)
+		}
+		if actualHeader.Version != expectedHeader.Version ||
+			actualHeader.Width != expectedHeader.Width ||
+			actualHeader.Height != expectedHeader.Height ||
+			actualHeader.Frames != expectedHeader.Frames ||
+			actualHeader.Reflectivity != expectedHeader.Reflectivity ||
+			actualHeader.HighResImageFormat != expectedHeader.HighResImageFormat ||
+			actualHeader.MipmapCount != expectedHeader.MipmapCount {
+			t.Errorf("7.%d: header mismatch: expected %+v, got %+v", version, expectedHeader, actualHeader)
+		}
+		if version >= 3 && actualHeader.NumResource != 2 {
+			t.Errorf("7.%d: expected 2 resources, got %d", version, actualHeader.NumResource)
+		}
+		if !bytes.Equal(actual.LowResImageData(), expected.LowResImageData()) {
+			t.Errorf("7.%d: low resolution data mismatch", version)
+		}
+		if !reflect.DeepEqual(actual.HighResImageData(), expected.HighResImageData()) {
+			t.Errorf("7.%d: high resolution data mismatch", version)
+		}
+	}
+}
+
+func TestWriteToStream_RoundTripSample(t *testing.T) {
+	expected, err := ReadFromFile("samples/read/test.vtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, expected); err != nil {
+		t.Fatal(err)
+	}
+	actual, err := ReadFromStream(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual.Header() != expected.Header() {
+		t.Errorf("header mismatch: expected %+v, got %+v", expected.Header(), actual.Header())
+	}
+	if !reflect.DeepEqual(actual.HighResImageData(), expected.HighResImageData()) {
+		t.Error("high resolution data mismatch")
+	}
+}
+
+func TestWriteToStream_RoundTripSmallerThanHeader(t *testing.T) {
+	header := newTestVtf(1, 1).Header()
+	header.Width, header.Height = 1, 1
+	header.MipmapCount = 1
+	header.LowResImageFormat = noLowResImageFormat
+	header.LowResImageWidth, header.LowResImageHeight = 0, 0
+	v := newTestVtfFromHeader(header)
+
+	var buf bytes.Buffer
+	if err := WriteToStream(&buf, v); err != nil {
+		t.Fatal(err)
+	}
+	// The whole file is shorter than Header
+	if buf.Len() >= binary.Size(Header{}) {
+		t.Fatalf("expected a file shorter than Header, got %d bytes", buf.Len())
+	}
+
+	streamed, err := ReadFromStream(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lazy, err := ReadFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, read := range []*Vtf{streamed, lazy} {
+		if read.Header().Width != 1 || read.Header().Version != header.Version {
+			t.Errorf("unexpected header %+v", read.Header())
+		}
+		if !bytes.Equal(read.Slice(0, 0, 0, 0), v.Slice(0, 0, 0, 0)) {
+			t.Error("mipmap data differs from the written texture")
+		}
+	}
+}
+
+func TestWriteToStream_Mismatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		modify        func(*Vtf)
+		expectedError error
+	}{
+		{
+			name: "mipmap count",
+			modify: func(v *Vtf) {
+				v.header.MipmapCount = 3
+			},
+			expectedError: ErrorImageDataMismatch,
+		},
+		{
+			name: "frame count",
+			modify: func(v *Vtf) {
+				v.header.Frames = 1
+			},
+			expectedError: ErrorImageDataMismatch,
+		},
+		{
+			name: "mipmap size",
+			modify: func(v *Vtf) {
+				v.highResolutionImageData[1][0][0][0] = []byte{1, 2, 3}
+			},
+			expectedError: ErrorImageDataMismatch,
+		},
+		{
+			name: "low resolution size",
+			modify: func(v *Vtf) {
+				v.lowResolutionImageData = []byte{1}
+			},
+			expectedError: ErrorImageDataMismatch,
+		},
+		{
+			name: "version",
+			modify: func(v *Vtf) {
+				v.header.Version = [2]uint32{7, 6}
+			},
+			expectedError: ErrorUnsupportedVersion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestVtf(5, 2)
+			tt.modify(v)
+			err := WriteToStream(&bytes.Buffer{}, v)
+			if !errors.Is(err, tt.expectedError) {
+				t.Errorf("expected error %v, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}